@@ -3,6 +3,8 @@ package bundle
 import (
 	"encoding/xml"
 	"fmt"
+	"io"
+	"slices"
 	"strconv"
 )
 
@@ -10,6 +12,8 @@ type entry struct {
 	Currency    string `xml:"Ccy"`
 	CurrencyNbr int    `xml:"CcyNbr"`
 	MinorUnits  units  `xml:"CcyMnrUnts"`
+	// Year and month of withdrawal, only for historic currencies.
+	Withdrawal string `xml:"WthdrwlDt"`
 }
 
 type units uint
@@ -27,14 +31,21 @@ func (m *units) UnmarshalText(b []byte) error {
 
 type CurrencyProvider struct {
 	Entries []entry `xml:"CcyTbl>CcyNtry,name"`
+	// Entries of ISO 4217 List Three, loaded only with WithHistoricCurrencies option.
+	Historic []entry `xml:"HstrcCcyTbl>HstrcCcyNtry"`
+	// Publication date of the newest loaded currency list.
+	Published string `xml:"Pblshd,attr"`
+
+	// Index of loaded codes, built by Load.
+	codes map[string]bool
 }
 
-// NewCurrencyProvider creates new currency data provider.
-func NewCurrencyProvider() (*CurrencyProvider, error) {
+// NewCurrencyProvider creates new currency data provider, default options are used when none are given.
+func NewCurrencyProvider(opts ...Option) (*CurrencyProvider, error) {
 	cp := &CurrencyProvider{
 		Entries: []entry{},
 	}
-	if err := cp.Load(); err != nil {
+	if err := cp.load(resolveOptions(opts)); err != nil {
 		return nil, fmt.Errorf("failed to load data: %w", err)
 	}
 
@@ -43,25 +54,94 @@ func NewCurrencyProvider() (*CurrencyProvider, error) {
 
 // Load loads data from embedded file.
 func (cp *CurrencyProvider) Load() error {
-	file, err := EmbedFS.Open("resources/iso4217.xml")
-	if err != nil {
-		return fmt.Errorf("failed to open currency data: %w", err)
+	return cp.load(options{})
+}
+
+func (cp *CurrencyProvider) load(o options) error {
+	files := []string{CurrencyFile}
+	if o.historic {
+		files = append(files, HistoricCurrencyFile)
 	}
-	decoder := xml.NewDecoder(file)
-	err = decoder.Decode(cp)
-	if err != nil {
-		return fmt.Errorf("failed to parse currency data: %w", err)
+	for _, name := range files {
+		err := readSources(o, name, func(r io.Reader) error {
+			data := &CurrencyProvider{}
+			if err := xml.NewDecoder(r).Decode(data); err != nil {
+				return err
+			}
+			cp.Entries = mergeEntries(cp.Entries, data.Entries)
+			cp.Historic = mergeEntries(cp.Historic, data.Historic)
+			if data.Published > cp.Published {
+				cp.Published = data.Published
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load currency data: %w", err)
+		}
+	}
+	cp.codes = map[string]bool{}
+	for _, code := range cp.List() {
+		if code != "" {
+			cp.codes[code] = true
+		}
 	}
 
 	return nil
 }
 
-// List provides a list of available currency codes for ISO4217.
+// IsValid checks if the currency code is present in the loaded lists.
+func (cp *CurrencyProvider) IsValid(code string) bool {
+	if cp.codes == nil {
+		return code != "" && slices.Contains(cp.List(), code)
+	}
+	return cp.codes[code]
+}
+
+// mergeEntries replaces base entries with the codes present in overlay and adds the other overlay entries.
+func mergeEntries(base, overlay []entry) []entry {
+	if len(overlay) == 0 {
+		return base
+	}
+	codes := map[string]bool{}
+	for _, e := range overlay {
+		codes[e.Currency] = true
+	}
+	result := []entry{}
+	for _, e := range base {
+		if !codes[e.Currency] {
+			result = append(result, e)
+		}
+	}
+
+	return append(result, overlay...)
+}
+
+// List provides a list of available currency codes for ISO4217, including historic ones if loaded.
 func (cp *CurrencyProvider) List() []string {
-	result := make([]string, len(cp.Entries))
-	for i, e := range cp.Entries {
-		result[i] = e.Currency
+	result := make([]string, 0, len(cp.Entries)+len(cp.Historic))
+	for _, e := range cp.Entries {
+		result = append(result, e.Currency)
+	}
+	for _, e := range cp.Historic {
+		result = append(result, e.Currency)
 	}
 
 	return result
 }
+
+// IsHistoric checks if the currency code is withdrawn one from ISO 4217 List Three.
+// Historic currencies are known only to providers created with WithHistoricCurrencies option.
+func (cp *CurrencyProvider) IsHistoric(code string) bool {
+	for _, e := range cp.Entries {
+		if e.Currency == code {
+			return false
+		}
+	}
+	for _, e := range cp.Historic {
+		if e.Currency == code {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Names of reference data files, looked up in the root of external file systems.
+const (
+	CurrencyFile           = "iso4217.xml"
+	HistoricCurrencyFile   = "iso4217-list3.xml"
+	StatementIdentCodeFile = "mt940sic.csv"
+)
+
+// Option configures sources of reference data of providers.
+type Option func(*options)
+
+type options struct {
+	// External file systems, entries of later ones override earlier ones and embedded data.
+	sources  []fs.FS
+	historic bool
+	// Bank-specific statement identification code tables in the search order.
+	codeTables []codeTable
+}
+
+// WithFS adds external reference data from the file system, files missing in it are skipped.
+// Entries override embedded ones with the same code, other entries are added.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.sources = append(o.sources, fsys)
+	}
+}
+
+// WithDir adds external reference data from the directory, see WithFS.
+func WithDir(path string) Option {
+	return WithFS(os.DirFS(path))
+}
+
+// WithHistoricCurrencies makes currency codes of ISO 4217 List Three (withdrawn currencies) valid,
+// e.g. for validation of archived statements.
+func WithHistoricCurrencies() Option {
+	return func(o *options) {
+		o.historic = true
+	}
+}
+
+// WithStatementIdentCodes adds bank-specific table of statement identification codes, e.g. numeric codes
+// like 911 used in N911. Codes are given without the transaction type letter. Tables are searched in the order
+// of options after the SWIFT codes, the table with the same name as an earlier one replaces it.
+func WithStatementIdentCodes(table string, codes map[string]CodeInfo) Option {
+	added := codeTable{name: table, codes: map[string]CodeInfo{}}
+	for code, info := range codes {
+		info.Code, info.Table = code, table
+		added.codes[code] = info
+	}
+	return func(o *options) {
+		if i := slices.IndexFunc(o.codeTables, func(t codeTable) bool { return t.name == table }); i >= 0 {
+			o.codeTables[i] = added
+			return
+		}
+		o.codeTables = append(o.codeTables, added)
+	}
+}
+
+var (
+	defaultsMu      sync.Mutex
+	defaultOptions  []Option
+	defaultCurrency *CurrencyProvider
+	defaultSIC      *StatementIdentCodeProvider
+	// Incremented by SetDefaultOptions, providers built with older options are not cached.
+	defaultsGeneration int
+)
+
+// SetDefaultOptions sets options used by providers created without options,
+// including the ones used in message validation.
+func SetDefaultOptions(opts ...Option) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	defaultOptions = opts
+	defaultCurrency, defaultSIC = nil, nil
+	defaultsGeneration++
+}
+
+// resolveOptions returns options of the provider, default ones are used when none are given.
+func resolveOptions(opts []Option) options {
+	if len(opts) == 0 {
+		defaultsMu.Lock()
+		opts = defaultOptions
+		defaultsMu.Unlock()
+	}
+	result := options{}
+	for _, opt := range opts {
+		opt(&result)
+	}
+	return result
+}
+
+// defaultProvider returns the cached provider or builds it with current default options. The provider
+// is cached only when default options did not change while it was built, otherwise it is built again.
+func defaultProvider[T any](cached **T, build func(opts ...Option) (*T, error)) (*T, error) {
+	for {
+		defaultsMu.Lock()
+		provider, generation := *cached, defaultsGeneration
+		defaultsMu.Unlock()
+		if provider != nil {
+			return provider, nil
+		}
+		provider, err := build()
+		if err != nil {
+			return nil, err
+		}
+		defaultsMu.Lock()
+		if generation == defaultsGeneration {
+			*cached = provider
+			defaultsMu.Unlock()
+			return provider, nil
+		}
+		defaultsMu.Unlock()
+	}
+}
+
+// DefaultCurrencyProvider returns shared currency provider created with default options.
+func DefaultCurrencyProvider() (*CurrencyProvider, error) {
+	return defaultProvider(&defaultCurrency, NewCurrencyProvider)
+}
+
+// DefaultStatementIdentCodeProvider returns shared statement identification code provider created with default options.
+func DefaultStatementIdentCodeProvider() (*StatementIdentCodeProvider, error) {
+	return defaultProvider(&defaultSIC, NewStatementIdentificationCodeProvider)
+}
+
+// readSources calls read for the embedded resource and the file in every external source.
+func readSources(o options, name string, read func(r io.Reader) error) error {
+	file, err := EmbedFS.Open("resources/" + name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+	if err := read(file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	for _, fsys := range o.sources {
+		file, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open external %s: %w", name, err)
+		}
+		err = read(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse external %s: %w", name, err)
+		}
+	}
+	return nil
+}
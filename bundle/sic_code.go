@@ -3,44 +3,158 @@ package bundle
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
 )
 
+// SWIFTCodeTable is the name of the code table of SWIFT statement identification codes.
+const SWIFTCodeTable = "SWIFT"
+
+// CodeInfo describes a transaction type code.
+type CodeInfo struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Category    string `json:"category,omitempty"`
+	// Name of the code table, SWIFT or bank-specific one.
+	Table string `json:"table"`
+}
+
 type StatementIdentCodeProvider struct {
 	Codes map[string]string
+	// Categories of the codes, e.g. Transfer or Fees.
+	Categories map[string]string
+	// Bank-specific code tables searched after the SWIFT codes.
+	tables []codeTable
+}
+
+// codeTable is a bank-specific table of statement identification codes.
+type codeTable struct {
+	name  string
+	codes map[string]CodeInfo
+}
+
+// ReadStatementIdentCodes reads CSV records of code, description and optional category of the bank-specific
+// code table, the result can be used with WithStatementIdentCodes option.
+func ReadStatementIdentCodes(table string, r io.Reader) (map[string]CodeInfo, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse code table %s: %w", table, err)
+	}
+	codes := map[string]CodeInfo{}
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("bad record %d of code table %s: expected code and description", i+1, table)
+		}
+		info := CodeInfo{Description: record[1]}
+		if len(record) > 2 {
+			info.Category = record[2]
+		}
+		codes[record[0]] = info
+	}
+	return codes, nil
 }
 
-// NewStatementIdentificationCodeProvider creates new MT940 statement identification code provider.
-func NewStatementIdentificationCodeProvider() (*StatementIdentCodeProvider, error) {
+// NewStatementIdentificationCodeProvider creates new MT940 statement identification code provider,
+// default options are used when none are given.
+func NewStatementIdentificationCodeProvider(opts ...Option) (*StatementIdentCodeProvider, error) {
 	result := &StatementIdentCodeProvider{
-		Codes: make(map[string]string),
+		Codes:      make(map[string]string),
+		Categories: make(map[string]string),
 	}
-	if err := result.Load(); err != nil {
+	o := resolveOptions(opts)
+	if err := result.load(o); err != nil {
 		return nil, fmt.Errorf("error loading statement identification codes: %w", err)
 	}
+	result.tables = o.codeTables
 
 	return result, nil
 }
 
 // Load loads data from embedded file.
 func (cp *StatementIdentCodeProvider) Load() error {
-	file, err := EmbedFS.Open("resources/mt940sic.csv")
-	if err != nil {
-		return fmt.Errorf("failed to open identification data: %w", err)
-	}
-	csvReader := csv.NewReader(file)
-	records, err := csvReader.ReadAll()
+	return cp.load(options{})
+}
+
+func (cp *StatementIdentCodeProvider) load(o options) error {
+	err := readSources(o, StatementIdentCodeFile, func(r io.Reader) error {
+		csvReader := csv.NewReader(r)
+		csvReader.FieldsPerRecord = -1
+		records, err := csvReader.ReadAll()
+		if err != nil {
+			return err
+		}
+		for i, record := range records {
+			if len(record) < 2 {
+				return fmt.Errorf("record %d: expected code and description", i+1)
+			}
+			cp.Codes[record[0]] = record[1]
+			cp.Categories[record[0]] = ""
+			if len(record) > 2 {
+				cp.Categories[record[0]] = record[2]
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to parse identification data: %w", err)
-	}
-	for _, record := range records {
-		cp.Codes[record[0]] = record[1]
+		return fmt.Errorf("failed to load identification data: %w", err)
 	}
 
 	return nil
 }
 
-// IsProperCode checks if provided statement identification code is proper.
+// IsProperCode checks if provided statement identification code is proper,
+// i.e. it is a SWIFT code or a code of bank-specific table of the provider.
 func (cp *StatementIdentCodeProvider) IsProperCode(code string) bool {
-	_, ok := cp.Codes[code]
+	_, ok := cp.Info(code)
 	return ok
 }
+
+// Info returns description of the statement identification code (without the transaction type letter).
+func (cp *StatementIdentCodeProvider) Info(code string) (CodeInfo, bool) {
+	if info, ok := cp.swiftInfo(code); ok {
+		return info, true
+	}
+	for _, table := range cp.tables {
+		if info, ok := table.codes[code]; ok {
+			return info, true
+		}
+	}
+	return CodeInfo{}, false
+}
+
+// swiftInfo returns description of the SWIFT statement identification code (without the transaction type letter).
+func (cp *StatementIdentCodeProvider) swiftInfo(code string) (CodeInfo, bool) {
+	if description, ok := cp.Codes[code]; ok {
+		return CodeInfo{Code: code, Description: description, Category: cp.Categories[code], Table: SWIFTCodeTable}, true
+	}
+	return CodeInfo{}, false
+}
+
+// Lookup returns description of the transaction type identification code from the statement line, e.g. NTRF.
+// Codes with S type letter are described as SWIFT message types.
+func (cp *StatementIdentCodeProvider) Lookup(transactionIdent string) (CodeInfo, bool) {
+	return lookup(transactionIdent, cp.Info)
+}
+
+// LookupSWIFT works as Lookup, but bank-specific code tables are not searched.
+func (cp *StatementIdentCodeProvider) LookupSWIFT(transactionIdent string) (CodeInfo, bool) {
+	return lookup(transactionIdent, cp.swiftInfo)
+}
+
+func lookup(transactionIdent string, info func(code string) (CodeInfo, bool)) (CodeInfo, bool) {
+	kind, size := utf8.DecodeRuneInString(transactionIdent)
+	code := transactionIdent[size:]
+	switch kind {
+	case 'S':
+		if _, err := strconv.Atoi(code); err != nil {
+			return CodeInfo{}, false
+		}
+		return CodeInfo{Code: code, Description: "SWIFT MT" + code, Category: "SWIFT message", Table: SWIFTCodeTable}, true
+	case 'N', 'F':
+		return info(code)
+	}
+	return CodeInfo{}, false
+}
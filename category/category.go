@@ -0,0 +1,189 @@
+// Package category assigns categories to statement transactions using ordered rules.
+package category
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/oswida/mt9x/grammar"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule assigns categories to matching transactions. Empty criteria match every transaction,
+// so the rule without criteria can be used as a fallback.
+type Rule struct {
+	// Name of the rule, stored with the assigned categories.
+	Name       string   `json:"name" yaml:"name"`
+	Categories []string `json:"categories" yaml:"categories"`
+	// Transaction identification code, with (NTRF) or without (TRF) the type letter.
+	TransactionCode string `json:"trx_code,omitempty" yaml:"trx_code,omitempty"`
+	// Debit/credit mark of the statement line (D, C, RD, RC).
+	DCMark string `json:"dc_mark,omitempty" yaml:"dc_mark,omitempty"`
+	// Range of the transaction amount (without sign), limits are inclusive.
+	MinAmount *decimal.Decimal `json:"min_amount,omitempty" yaml:"min_amount,omitempty"`
+	MaxAmount *decimal.Decimal `json:"max_amount,omitempty" yaml:"max_amount,omitempty"`
+	// Regular expression matched against information to account owner (:86: lines joined with space).
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Regular expression matched against the owner or the institution reference.
+	Reference string `json:"reference,omitempty" yaml:"reference,omitempty"`
+	// Regular expressions matched against decoded counterparty of the transaction.
+	CounterpartyName    string `json:"counterparty_name,omitempty" yaml:"counterparty_name,omitempty"`
+	CounterpartyAccount string `json:"counterparty_account,omitempty" yaml:"counterparty_account,omitempty"`
+	// Continue with next rules after match, by default the first matching rule wins.
+	Continue bool `json:"continue,omitempty" yaml:"continue,omitempty"`
+
+	pattern, reference, name, account *regexp.Regexp
+}
+
+// Rules is an ordered set of categorisation rules.
+type Rules struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// LoadRules reads YAML or JSON rule set from reader.
+func LoadRules(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	// JSON is a subset of YAML, so both are decoded by the YAML decoder.
+	if err := yaml.NewDecoder(r).Decode(rules); err != nil {
+		return nil, fmt.Errorf("failed to decode categorisation rules: %w", err)
+	}
+	if err := rules.Compile(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// LoadRulesFile reads YAML or JSON rule set from file.
+func LoadRulesFile(filename string) (*Rules, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	return LoadRules(f)
+}
+
+// Compile checks the rules and compiles their regular expressions. It is called by LoadRules,
+// rules created in code are compiled on the first match otherwise.
+func (rs *Rules) Compile() error {
+	for i := range rs.Rules {
+		r := &rs.Rules[i]
+		if len(r.Categories) == 0 {
+			return fmt.Errorf("categorisation rule %d has no categories", i)
+		}
+		if err := r.compile(); err != nil {
+			return fmt.Errorf("bad pattern in categorisation rule %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// compile compiles regular expressions of the rule which are not compiled yet.
+func (r *Rule) compile() error {
+	for _, re := range []struct {
+		pattern string
+		target  **regexp.Regexp
+	}{
+		{r.Pattern, &r.pattern},
+		{r.Reference, &r.reference},
+		{r.CounterpartyName, &r.name},
+		{r.CounterpartyAccount, &r.account},
+	} {
+		if re.pattern == "" || *re.target != nil {
+			continue
+		}
+		compiled, err := regexp.Compile(re.pattern)
+		if err != nil {
+			return err
+		}
+		*re.target = compiled
+	}
+
+	return nil
+}
+
+// matchesCode checks transaction identification code with (NTRF) or without (TRF) the type letter.
+func matchesCode(code, ti string) bool {
+	return code == ti || (len(ti) > 1 && code == ti[1:])
+}
+
+// matches checks if rule criteria are fulfilled by the transaction.
+func (r *Rule) matches(t grammar.Transaction) (bool, error) {
+	if r.TransactionCode != "" && !matchesCode(r.TransactionCode, t.TransactionIdent) {
+		return false, nil
+	}
+	if r.DCMark != "" && r.DCMark != string(t.DCMark) {
+		return false, nil
+	}
+	if r.MinAmount != nil && t.Amount.LessThan(*r.MinAmount) {
+		return false, nil
+	}
+	if r.MaxAmount != nil && t.Amount.GreaterThan(*r.MaxAmount) {
+		return false, nil
+	}
+	if err := r.compile(); err != nil {
+		return false, fmt.Errorf("bad pattern in categorisation rule %s: %w", r.Name, err)
+	}
+	if r.pattern != nil && !r.pattern.MatchString(strings.Join(t.AccountOwnerInfo, " ")) {
+		return false, nil
+	}
+	if r.reference != nil && !r.reference.MatchString(t.Reference) && !r.reference.MatchString(t.InstitutionReference) {
+		return false, nil
+	}
+	if r.name != nil && !r.name.MatchString(t.OwnerInfo.CounterpartyName) {
+		return false, nil
+	}
+	if r.account != nil && !r.account.MatchString(t.OwnerInfo.CounterpartyAccount) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Match returns categories of the transaction assigned by matching rules.
+func (rs *Rules) Match(t grammar.Transaction) ([]grammar.Category, error) {
+	result := []grammar.Category{}
+	for i := range rs.Rules {
+		r := &rs.Rules[i]
+		ok, err := r.matches(t)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		for _, c := range r.Categories {
+			result = append(result, grammar.Category{Name: c, Rule: r.Name})
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Categorize assigns categories to statement sections of the message, replacing previous ones.
+// The message is not changed when a rule cannot be matched.
+func (rs *Rules) Categorize(m *grammar.MT940Message) error {
+	assigned := make([][]grammar.Category, len(m.Statements))
+	for _, t := range m.Transactions() {
+		categories, err := rs.Match(t)
+		if err != nil {
+			return err
+		}
+		if len(categories) > 0 {
+			assigned[t.Index] = categories
+		}
+	}
+	for i, categories := range assigned {
+		m.Statements[i].Categories = categories
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package category_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/category"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+const yamlRules = `
+rules:
+  - name: large-incoming
+    categories: [large]
+    dc_mark: C
+    min_amount: 10000
+    continue: true
+  - name: invoices
+    categories: [supplier, invoice]
+    dc_mark: D
+    pattern: INVOICE
+  - name: treasury
+    categories: [treasury]
+    counterparty_name: ^TREASURY
+  - name: own-reference
+    categories: [internal]
+    reference: ^OWN REFERENCE
+  - name: other
+    categories: [uncategorized]
+`
+
+func TestCategorize(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt940", "input", "io-payments.sta"), false, nil)
+	assert.NoError(t, err)
+	rules, err := category.LoadRules(strings.NewReader(yamlRules))
+	assert.NoError(t, err)
+	assert.NoError(t, rules.Categorize(m))
+	result := []string{}
+	for _, ss := range m.Statements {
+		names := []string{}
+		for _, c := range ss.Categories {
+			names = append(names, c.Rule+":"+c.Name)
+		}
+		result = append(result, strings.Join(names, ","))
+	}
+	assert.Equal(t, []string{
+		"other:uncategorized",
+		"large-incoming:large,treasury:treasury",
+		"other:uncategorized",
+		"other:uncategorized",
+		"invoices:supplier,invoices:invoice",
+		"invoices:supplier,invoices:invoice",
+	}, result)
+
+	rules, err = category.LoadRules(strings.NewReader(`{"rules": [{"name": "fees", "categories": ["fee"], "trx_code": "CHG", "max_amount": "10.5"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.5", rules.Rules[0].MaxAmount.String())
+
+	_, err = category.LoadRules(strings.NewReader(`rules: [{name: bad, categories: [x], pattern: "("}]`))
+	assert.EqualError(t, err, "bad pattern in categorisation rule 0: error parsing regexp: missing closing ): `(`")
+	_, err = category.LoadRules(strings.NewReader(`rules: [{name: empty}]`))
+	assert.EqualError(t, err, "categorisation rule 0 has no categories")
+}
+
+func TestRulesInCode(t *testing.T) {
+	rules := &category.Rules{Rules: []category.Rule{
+		{Name: "fees", Categories: []string{"fee"}, TransactionCode: "CHG"},
+		{Name: "rent", Categories: []string{"rent"}, Pattern: "RENT"},
+	}}
+	categories, err := rules.Match(grammar.Transaction{TransactionIdent: "NTRF", AccountOwnerInfo: []string{"OFFICE RENT"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []grammar.Category{{Name: "rent", Rule: "rent"}}, categories)
+	// Transaction without identification code does not match the code.
+	categories, err = rules.Match(grammar.Transaction{AccountOwnerInfo: []string{"INVOICE"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []grammar.Category{}, categories)
+
+	rules.Rules = append(rules.Rules, category.Rule{Name: "bad", Categories: []string{"x"}, Reference: "("})
+	_, err = rules.Match(grammar.Transaction{})
+	assert.EqualError(t, err, "bad pattern in categorisation rule bad: error parsing regexp: missing closing ): `(`")
+}
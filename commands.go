@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/oswida/mt9x/export"
+	"github.com/oswida/mt9x/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// environment holds standard streams used by commands.
+type environment struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// commonFlags are accepted by all commands.
+type commonFlags struct {
+	messageType string
+	trace       bool
+}
+
+func (env *environment) newFlagSet(name string, cf *commonFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.StringVar(&cf.messageType, "message-type", "940", "message type number or auto to detect type of every message")
+	fs.BoolVar(&cf.trace, "trace", false, "write parser trace to stderr")
+	return fs
+}
+
+// traceWriter returns writer for parser trace or nil when tracing is disabled.
+func (env *environment) traceWriter(cf *commonFlags) io.Writer {
+	if cf.trace {
+		return env.stderr
+	}
+	return nil
+}
+
+// parsed is a result of parsing single input.
+type parsed struct {
+	input   string
+	message parser.MT9xMessage
+	err     error
+}
+
+// parseInputs reads and parses all inputs, errors are kept in results.
+func (env *environment) parseInputs(args []string, cf *commonFlags) ([]parsed, error) {
+	kind, err := kindOf(cf.messageType)
+	if err != nil {
+		return nil, err
+	}
+	return env.parseInputsAs(args, kind, cf)
+}
+
+// parseInputsAs reads and parses all inputs with the message kind. Inputs containing many messages are split
+// as in batch mode and every message is a separate result named with its number, e.g. file.sta#2.
+func (env *environment) parseInputsAs(args []string, kind messageKind, cf *commonFlags) ([]parsed, error) {
+	inputs, err := readInputs(args, env.stdin)
+	if err != nil {
+		return nil, err
+	}
+	result := []parsed{}
+	for _, in := range inputs {
+		messages := parser.SplitMessages(in.data)
+		if len(messages) < 2 {
+			// Input without messages is parsed as a whole, so the parser reports the problem.
+			messages = [][]byte{in.data}
+		}
+		for i, data := range messages {
+			name := in.name
+			if len(messages) > 1 {
+				name = fmt.Sprintf("%s#%d", in.name, i+1)
+			}
+			m, err := kind.parse(data, env.traceWriter(cf))
+			result = append(result, parsed{input: name, message: m, err: err})
+		}
+	}
+	return result, nil
+}
+
+// toYAML converts value to YAML using its JSON representation, so keys and order are the same as in JSON.
+func toYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	resetStyle(node)
+	return yaml.Marshal(node)
+}
+
+// resetStyle removes JSON flow style from YAML nodes.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetStyle(n)
+	}
+}
+
+func (env *environment) parseCommand(args []string) int {
+	cf := &commonFlags{}
+	fs := env.newFlagSet("parse", cf)
+	format := fs.String("format", "json", "output format: json or yaml")
+	validate := fs.Bool("validate", false, "validate parsed messages")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "json" && *format != "yaml" {
+		fmt.Fprintf(env.stderr, "unknown output format: %s\n", *format)
+		return 2
+	}
+	results, err := env.parseInputs(fs.Args(), cf)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return 1
+	}
+	code := 0
+	written := 0
+	for _, r := range results {
+		if r.err == nil && *validate {
+			r.err = r.message.Validate()
+		}
+		if r.err != nil {
+			fmt.Fprintf(env.stderr, "%s: %v\n", r.input, r.err)
+			code = 1
+			continue
+		}
+		var data []byte
+		if *format == "yaml" {
+			data, err = toYAML(r.message)
+			if written > 0 {
+				data = append([]byte("---\n"), data...)
+			}
+		} else {
+			data, err = json.MarshalIndent(r.message, "", " ")
+			data = append(data, '\n')
+		}
+		if err != nil {
+			fmt.Fprintf(env.stderr, "%s: %v\n", r.input, err)
+			code = 1
+			continue
+		}
+		env.stdout.Write(data)
+		written++
+	}
+	return code
+}
+
+const (
+	statusOK              = "ok"
+	statusParseError      = "parse error"
+	statusValidationError = "validation error"
+)
+
+// reportEntry is a validation result of a single input.
+type reportEntry struct {
+	Input  string `json:"input"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (env *environment) validateCommand(args []string) int {
+	cf := &commonFlags{}
+	fs := env.newFlagSet("validate", cf)
+	report := fs.String("report", "text", "report format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *report != "text" && *report != "json" {
+		fmt.Fprintf(env.stderr, "unknown report format: %s\n", *report)
+		return 2
+	}
+	results, err := env.parseInputs(fs.Args(), cf)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return 1
+	}
+	code := 0
+	entries := make([]reportEntry, len(results))
+	for i, r := range results {
+		entries[i] = reportEntry{Input: r.input, Status: statusOK}
+		if r.err != nil {
+			entries[i].Status = statusParseError
+			entries[i].Error = r.err.Error()
+		} else if err := r.message.Validate(); err != nil {
+			entries[i].Status = statusValidationError
+			entries[i].Error = err.Error()
+		}
+		if entries[i].Status != statusOK {
+			code = 1
+		}
+	}
+	if *report == "json" {
+		data, _ := json.MarshalIndent(entries, "", " ")
+		fmt.Fprintln(env.stdout, string(data))
+		return code
+	}
+	for _, e := range entries {
+		if e.Error != "" {
+			fmt.Fprintf(env.stdout, "%s: %s: %s\n", e.Input, e.Status, e.Error)
+		} else {
+			fmt.Fprintf(env.stdout, "%s: %s\n", e.Input, e.Status)
+		}
+	}
+	return code
+}
+
+func (env *environment) convertCommand(args []string) int {
+	cf := &commonFlags{}
+	fs := env.newFlagSet("convert", cf)
+	to := fs.String("to", "", "output format: csv, camt053, ofx, mt940, beancount or ledger (mt900, mt910, mt920, mt941, mt942, mt971, mt973 for other types)")
+	rulesFile := fs.String("rules", "", "JSON journal rules for beancount and ledger formats")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	kind, err := kindOf(cf.messageType)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return 2
+	}
+	conv, ok := kind.converters[*to]
+	if !ok {
+		formats := []string{}
+		for k := range kind.converters {
+			formats = append(formats, k)
+		}
+		slices.Sort(formats)
+		fmt.Fprintf(env.stderr, "unknown output format %q for MT%s, available: %s\n",
+			*to, cf.messageType, strings.Join(formats, ", "))
+		return 2
+	}
+	var rules *export.JournalRules
+	if *rulesFile != "" {
+		if rules, err = export.LoadJournalRulesFile(*rulesFile); err != nil {
+			fmt.Fprintln(env.stderr, err)
+			return 1
+		}
+	}
+	results, err := env.parseInputsAs(fs.Args(), kind, cf)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return 1
+	}
+	code := 0
+	messages := []parser.MT9xMessage{}
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(env.stderr, "%s: %v\n", r.input, r.err)
+			code = 1
+			continue
+		}
+		messages = append(messages, r.message)
+	}
+	if len(messages) == 0 {
+		return 1
+	}
+	if err := conv(env.stdout, messages, rules); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return 1
+	}
+	return code
+}
+
+func (env *environment) batchCommand(args []string) int {
+	cf := &commonFlags{}
+	fs := env.newFlagSet("batch", cf)
+	opts := parser.BatchOptions{}
+	fs.IntVar(&opts.Workers, "workers", 0, "number of concurrent workers, number of CPUs by default")
+	fs.StringVar(&opts.Pattern, "pattern", "", "glob pattern for file names, e.g. *.sta")
+	fs.BoolVar(&opts.Validate, "validate", false, "validate parsed messages")
+	report := fs.String("report", "text", "report format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *report != "text" && *report != "json" {
+		fmt.Fprintf(env.stderr, "unknown report format: %s\n", *report)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(env.stderr, "no directory given")
+		return 2
+	}
+	kind, err := kindOf(cf.messageType)
+	if err == nil && kind.batch == nil {
+		err = fmt.Errorf("batch requires message type number")
+	}
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	code := 0
+	summaries := []parser.FileSummary{}
+	for _, dir := range fs.Args() {
+		result, err := kind.batch(ctx, dir, opts)
+		summaries = append(summaries, result...)
+		if err != nil {
+			fmt.Fprintf(env.stderr, "%s: %v\n", dir, err)
+			code = 1
+			break
+		}
+	}
+	for _, s := range summaries {
+		if s.Status != parser.StatusOK {
+			code = 1
+		}
+	}
+	if *report == "json" {
+		data, _ := json.MarshalIndent(summaries, "", " ")
+		fmt.Fprintln(env.stdout, string(data))
+		return code
+	}
+	for _, s := range summaries {
+		if s.Error != "" {
+			fmt.Fprintf(env.stdout, "%s: %s: %s\n", s.Path, s.Status, s.Error)
+		} else {
+			fmt.Fprintf(env.stdout, "%s: %s, %d message(s)\n", s.Path, s.Status, s.MessageCount)
+		}
+	}
+	return code
+}
@@ -0,0 +1,181 @@
+// Package dedup detects transactions booked more than once, e.g. when a bank re-sends statements.
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oswida/mt9x/grammar"
+)
+
+// Kinds of duplicates.
+const (
+	// All fingerprinted data of the transaction are the same.
+	Exact = "exact"
+	// Account, value date and signed amount are the same, but references or details differ.
+	Probable = "probable"
+)
+
+// Record is a transaction remembered by the store.
+type Record struct {
+	Fingerprint string `json:"fingerprint"`
+	ProbableKey string `json:"probable_key"`
+	// Origin of the transaction given to the detector, e.g. file name.
+	Source      string `json:"source"`
+	Account     string `json:"account"`
+	StatementNo string `json:"stmt_number"`
+	SequenceNo  string `json:"seq_number,omitempty"`
+	// Position of the statement line in the message.
+	Index int `json:"index"`
+	// Number of the identical statement line in the message, see Fingerprint.
+	Occurrence int    `json:"occurrence"`
+	ValueDate  string `json:"value_date"`
+	Amount     string `json:"signed_amount"`
+	Reference  string `json:"owner_ref"`
+}
+
+// Result is the outcome of duplicate check of one transaction.
+type Result struct {
+	Transaction grammar.Transaction `json:"transaction"`
+	Record      Record              `json:"record"`
+	// Exact, Probable or empty for new transactions.
+	Kind string `json:"kind,omitempty"`
+	// Earlier records the transaction duplicates.
+	Matches []Record `json:"matches,omitempty"`
+}
+
+// Fingerprint returns hash of transaction data identifying exactly the same transaction: account, value date,
+// amount, mark, transaction type, references and information to account owner. Statement number and position
+// of the line are not used, so transactions re-sent in other statements are found. Occurrence is the number
+// of the identical statement line in its message, starting from 1, so identical lines of one message,
+// e.g. fees without reference, have different fingerprints.
+func Fingerprint(t grammar.Transaction, occurrence int) string {
+	fields := []string{
+		t.Account,
+		t.ValueDate.Format(time.DateOnly),
+		t.Amount.String(),
+		string(t.DCMark),
+		t.TransactionIdent,
+		t.Reference,
+		t.InstitutionReference,
+		t.OwnerInfo.Text,
+	}
+	if occurrence > 1 {
+		fields = append(fields, strconv.Itoa(occurrence))
+	}
+	hash := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// ProbableKey returns key of transactions which are probably the same: account, value date and signed amount.
+func ProbableKey(t grammar.Transaction) string {
+	return strings.Join([]string{t.Account, t.ValueDate.Format(time.DateOnly), t.SignedAmount.String()}, "|")
+}
+
+// NewRecord creates store record of the transaction, occurrence is the number of the identical statement line
+// in its message (see Fingerprint).
+func NewRecord(source string, t grammar.Transaction, occurrence int) Record {
+	return Record{
+		Fingerprint: Fingerprint(t, occurrence),
+		ProbableKey: ProbableKey(t),
+		Source:      source,
+		Account:     t.Account,
+		StatementNo: t.StatementNo,
+		SequenceNo:  t.SequenceNo,
+		Index:       t.Index,
+		Occurrence:  occurrence,
+		ValueDate:   t.ValueDate.Format(time.DateOnly),
+		Amount:      t.SignedAmount.String(),
+		Reference:   t.Reference,
+	}
+}
+
+// sameMessage checks if the other record comes from the same message of the same source.
+func (r Record) sameMessage(other Record) bool {
+	return r.Source == other.Source && r.Account == other.Account && r.StatementNo == other.StatementNo && r.SequenceNo == other.SequenceNo
+}
+
+// Store keeps records of already seen transactions.
+type Store interface {
+	// Find returns records with the fingerprint.
+	Find(fingerprint string) ([]Record, error)
+	// FindProbable returns records with the probable key.
+	FindProbable(key string) ([]Record, error)
+	// Add remembers the records.
+	Add(records ...Record) error
+}
+
+// Detector checks transactions against the store of already seen ones.
+type Detector struct {
+	store Store
+}
+
+// NewDetector creates duplicate detector using the store.
+func NewDetector(store Store) *Detector {
+	return &Detector{store: store}
+}
+
+// Check checks transactions of the messages, which come from the source (e.g. file name).
+// New and probably duplicated transactions are added to the store, so duplicates within the messages
+// are detected as well. Transactions of one message are not probable duplicates of each other.
+func (d *Detector) Check(source string, messages ...grammar.MT940Message) ([]Result, error) {
+	result := []Result{}
+	for _, m := range messages {
+		results, err := d.checkMessage(source, m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, results...)
+	}
+	return result, nil
+}
+
+func (d *Detector) checkMessage(source string, m grammar.MT940Message) ([]Result, error) {
+	result := []Result{}
+	// Occurrences of identical statement lines in the message.
+	occurrences := map[string]int{}
+	for _, t := range m.Transactions() {
+		base := Fingerprint(t, 1)
+		occurrences[base]++
+		r := Result{Transaction: t, Record: NewRecord(source, t, occurrences[base])}
+		matches, err := d.store.Find(r.Record.Fingerprint)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find transaction %s: %w", r.Record.Fingerprint, err)
+		}
+		if len(matches) > 0 {
+			r.Kind, r.Matches = Exact, matches
+			result = append(result, r)
+			continue
+		}
+		matches, err = d.store.FindProbable(r.Record.ProbableKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find transaction %s: %w", r.Record.ProbableKey, err)
+		}
+		// Other statement lines of the same message are not duplicates.
+		matches = slices.DeleteFunc(matches, r.Record.sameMessage)
+		if len(matches) > 0 {
+			r.Kind, r.Matches = Probable, matches
+		}
+		if err := d.store.Add(r.Record); err != nil {
+			return nil, fmt.Errorf("cannot store transaction %s: %w", r.Record.Fingerprint, err)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// Duplicates returns only results of duplicated transactions.
+func Duplicates(results []Result) []Result {
+	duplicates := []Result{}
+	for _, r := range results {
+		if r.Kind != "" {
+			duplicates = append(duplicates, r)
+		}
+	}
+	return duplicates
+}
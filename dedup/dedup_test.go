@@ -0,0 +1,76 @@
+package dedup_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/dedup"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestDetector(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt940", "input", "io-payments.sta"), false, nil)
+	assert.NoError(t, err)
+	resent := *m
+	resent.Statements = append([]grammar.StatementSection(nil), m.Statements...)
+	resent.Statements[1].AccountOwnerInfo = []string{"/REMI/CORRECTED"}
+
+	path := filepath.Join(t.TempDir(), "seen.jsonl")
+	store, err := dedup.NewFileStore(path)
+	assert.NoError(t, err)
+	detector := dedup.NewDetector(store)
+	results, err := detector.Check("first.sta", *m)
+	assert.NoError(t, err)
+	assert.Equal(t, len(m.Statements), len(results))
+	assert.Zero(t, dedup.Duplicates(results))
+	assert.NoError(t, store.Close())
+
+	// Store is reloaded from the file.
+	store, err = dedup.NewFileStore(path)
+	assert.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, store.Close()) })
+	results, err = dedup.NewDetector(store).Check("second.sta", resent)
+	assert.NoError(t, err)
+	duplicates := dedup.Duplicates(results)
+	assert.Equal(t, len(m.Statements), len(duplicates))
+	assert.Equal(t, dedup.Exact, duplicates[0].Kind)
+	assert.Equal(t, "first.sta", duplicates[0].Matches[0].Source)
+	assert.Equal(t, dedup.Probable, duplicates[1].Kind)
+	assert.Equal(t, duplicates[1].Record.ProbableKey, duplicates[1].Matches[0].ProbableKey)
+
+	results, err = dedup.NewDetector(dedup.NewMemoryStore()).Check("twice.sta", *m, *m)
+	assert.NoError(t, err)
+	duplicates = dedup.Duplicates(results)
+	assert.Equal(t, len(m.Statements), len(duplicates))
+	assert.Equal(t, "twice.sta", duplicates[0].Matches[0].Source)
+
+	// Identical fees of one statement are not duplicates, but they are when the statement is received again.
+	fees, err := parser.NewByteParser[grammar.MT940Message]().Parse([]byte(":20:FEES\r\n:25:PL61109010140000071219812874\r\n:28C:7\r\n"+
+		":60F:C240603EUR100,00\r\n:61:2406030603D1,50NCHGNONREF\r\n:61:2406030603D1,50NCHGNONREF\r\n:62F:C240603EUR97,00\r\n"), false, nil)
+	assert.NoError(t, err)
+	detector = dedup.NewDetector(dedup.NewMemoryStore())
+	results, err = detector.Check("fees.sta", *fees)
+	assert.NoError(t, err)
+	assert.Zero(t, dedup.Duplicates(results))
+	results, err = detector.Check("fees-again.sta", *fees)
+	assert.NoError(t, err)
+	duplicates = dedup.Duplicates(results)
+	assert.Equal(t, 2, len(duplicates))
+	assert.Equal(t, dedup.Exact, duplicates[1].Kind)
+	assert.Equal(t, 1, len(duplicates[1].Matches))
+
+	// Statement re-sent with other number and split into parts has exact duplicates.
+	renumbered := *fees
+	renumbered.StatementNumber.StatementNo = "8"
+	first, second := renumbered, renumbered
+	first.Statements, second.Statements = fees.Statements[:1], fees.Statements[1:]
+	results, err = detector.Check("renumbered.sta", first, second)
+	assert.NoError(t, err)
+	duplicates = dedup.Duplicates(results)
+	assert.Equal(t, 2, len(duplicates))
+	assert.Equal(t, dedup.Exact, duplicates[0].Kind)
+	assert.Equal(t, dedup.Exact, duplicates[1].Kind)
+}
@@ -0,0 +1,128 @@
+package dedup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// MemoryStore keeps records in memory.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	byPrint  map[string][]Record
+	probable map[string][]Record
+}
+
+// NewMemoryStore creates empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{byPrint: map[string][]Record{}, probable: map[string][]Record{}}
+}
+
+// Find returns records with the fingerprint.
+func (s *MemoryStore) Find(fingerprint string) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Record(nil), s.byPrint[fingerprint]...), nil
+}
+
+// FindProbable returns records with the probable key.
+func (s *MemoryStore) FindProbable(key string) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Record(nil), s.probable[key]...), nil
+}
+
+// Add remembers the records.
+func (s *MemoryStore) Add(records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		s.add(r)
+	}
+	return nil
+}
+
+// add remembers the record, the caller holds the lock.
+func (s *MemoryStore) add(r Record) {
+	s.byPrint[r.Fingerprint] = append(s.byPrint[r.Fingerprint], r)
+	s.probable[r.ProbableKey] = append(s.probable[r.ProbableKey], r)
+}
+
+// FileStore keeps records in memory and appends them to a file with one JSON record per line.
+// The file is kept open until the store is closed.
+type FileStore struct {
+	*MemoryStore
+	path string
+	file *os.File
+}
+
+// NewFileStore creates store backed by the file, records already present in the file are loaded.
+// The file is created when the first record is added. The store must be closed after use.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open store %s: %w", path, err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		r := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("bad record in line %d of store %s: %w", line, path, err)
+		}
+		s.add(r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read store %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Add appends the records to the file and remembers them.
+func (s *FileStore) Add(records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("cannot open store %s: %w", s.path, err)
+		}
+		s.file = file
+	}
+	encoder := json.NewEncoder(s.file)
+	for _, r := range records {
+		if err := encoder.Encode(r); err != nil {
+			return fmt.Errorf("cannot write store %s: %w", s.path, err)
+		}
+		s.add(r)
+	}
+	return nil
+}
+
+// Close closes the store file, it is opened again when records are added.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("cannot close store %s: %w", s.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/oswida/mt9x/grammar"
+)
+
+// ISO 20022 Bank to Customer Statement, version camt.053.001.02 used by most of the banks.
+
+const (
+	Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+	camtDateTime     = "2006-01-02T15:04:05"
+	camtMaxInfo      = 500
+)
+
+type camtDocument struct {
+	XMLName xml.Name          `xml:"Document"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Stmt    camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	GrpHdr camtGroupHeader `xml:"GrpHdr"`
+	Stmts  []camtStatement `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MsgId   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	Id           string        `xml:"Id"`
+	ElctrncSeqNb string        `xml:"ElctrncSeqNb,omitempty"`
+	CreDtTm      string        `xml:"CreDtTm"`
+	Acct         camtAccount   `xml:"Acct"`
+	Bal          []camtBalance `xml:"Bal"`
+	Ntry         []camtEntry   `xml:"Ntry"`
+	AddtlStmtInf string        `xml:"AddtlStmtInf,omitempty"`
+}
+
+type camtAccount struct {
+	IBAN string  `xml:"Id>IBAN,omitempty"`
+	Othr string  `xml:"Id>Othr>Id,omitempty"`
+	Ccy  string  `xml:"Ccy"`
+	BIC  *string `xml:"Svcr>FinInstnId>BIC"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        string     `xml:"Dt>Dt"`
+}
+
+type camtEntry struct {
+	Amt          camtAmount `xml:"Amt"`
+	CdtDbtInd    string     `xml:"CdtDbtInd"`
+	RvslInd      bool       `xml:"RvslInd,omitempty"`
+	Sts          string     `xml:"Sts"`
+	BookgDt      string     `xml:"BookgDt>Dt"`
+	ValDt        string     `xml:"ValDt>Dt"`
+	AcctSvcrRef  string     `xml:"AcctSvcrRef,omitempty"`
+	BkTxCd       string     `xml:"BkTxCd>Prtry>Cd"`
+	BkTxCdIssr   string     `xml:"BkTxCd>Prtry>Issr"`
+	EndToEndId   *string    `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	AddtlNtryInf string     `xml:"AddtlNtryInf,omitempty"`
+}
+
+// camtCreditDebit converts balance mark to camt indicator.
+func camtCreditDebit(dcMark grammar.DCMark) string {
+	if dcMark.DecreasesBalance() {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// camtBalanceOf creates balance of given type code.
+func camtBalanceOf(code string, b grammar.Balance) camtBalance {
+	return camtBalance{
+		Code:      code,
+		Amt:       camtAmount{Ccy: b.Currency, Value: b.Amount.StringFixed(2)},
+		CdtDbtInd: camtCreditDebit(b.DCMark),
+		Dt:        b.Date.Format(time.DateOnly),
+	}
+}
+
+// truncate shortens text to given number of runes.
+func truncate(s string, length int) string {
+	r := []rune(s)
+	if len(r) > length {
+		return string(r[:length])
+	}
+	return s
+}
+
+// camtStatementOf converts MT940 message to camt statement.
+func camtStatementOf(m grammar.MT940Message) camtStatement {
+	stmt := camtStatement{
+		Id:           m.TransactionRefNo,
+		ElctrncSeqNb: strings.TrimLeft(m.StatementNumber.StatementNo, "0"),
+		CreDtTm:      m.ClosingBalance.Date.Format(camtDateTime),
+		Acct: camtAccount{
+			Ccy: m.OpeningBalance.Currency,
+			BIC: m.AccountIdentification.IdentCode,
+		},
+		AddtlStmtInf: truncate(strings.Join(m.AccountOwnerInfo, " "), camtMaxInfo),
+	}
+	account := strings.TrimPrefix(m.AccountIdentification.Account, "/")
+	if isIBAN(account) {
+		stmt.Acct.IBAN = account
+	} else {
+		stmt.Acct.Othr = account
+	}
+	if m.IntermediateOpening {
+		stmt.Bal = append(stmt.Bal, camtBalanceOf("PRCD", m.OpeningBalance))
+	} else {
+		stmt.Bal = append(stmt.Bal, camtBalanceOf("OPBD", m.OpeningBalance))
+	}
+	if m.IntermediateClosing {
+		stmt.Bal = append(stmt.Bal, camtBalanceOf("ITBD", m.ClosingBalance))
+	} else {
+		stmt.Bal = append(stmt.Bal, camtBalanceOf("CLBD", m.ClosingBalance))
+	}
+	if m.ClosingAvailableBalance != nil {
+		stmt.Bal = append(stmt.Bal, camtBalanceOf("CLAV", *m.ClosingAvailableBalance))
+	}
+	for _, fab := range m.ForwardAvailableBalance {
+		stmt.Bal = append(stmt.Bal, camtBalanceOf("FWAV", fab))
+	}
+	for _, ss := range m.Statements {
+		s := ss.Statement
+		entry := camtEntry{
+			Amt:          camtAmount{Ccy: m.OpeningBalance.Currency, Value: s.Amount.StringFixed(2)},
+			CdtDbtInd:    "CRDT",
+			RvslInd:      s.IsReversal(),
+			Sts:          "BOOK",
+			BookgDt:      s.BookingDate().Format(time.DateOnly),
+			ValDt:        s.ValueDate.Format(time.DateOnly),
+			AcctSvcrRef:  orEmpty(s.InstitutionReference),
+			BkTxCd:       s.TransactionIdent,
+			BkTxCdIssr:   "SWIFT",
+			AddtlNtryInf: truncate(narration(ss), camtMaxInfo),
+		}
+		if s.DCMark.DecreasesBalance() {
+			entry.CdtDbtInd = "DBIT"
+		}
+		if ref := strings.TrimSpace(s.Reference); ref != "" {
+			entry.EndToEndId = &ref
+		}
+		stmt.Ntry = append(stmt.Ntry, entry)
+	}
+
+	return stmt
+}
+
+// isIBAN checks if account number has IBAN format.
+func isIBAN(account string) bool {
+	if len(account) < 15 || len(account) > 34 {
+		return false
+	}
+	for i, r := range account {
+		switch {
+		case i < 2 && (r < 'A' || r > 'Z'):
+			return false
+		case i >= 2 && i < 4 && (r < '0' || r > '9'):
+			return false
+		case (r < 'A' || r > 'Z') && (r < '0' || r > '9'):
+			return false
+		}
+	}
+	return true
+}
+
+// lastClosingDate returns the latest closing balance date of the messages.
+func lastClosingDate(messages []grammar.MT940Message) time.Time {
+	result := time.Time{}
+	for _, m := range messages {
+		if m.ClosingBalance.Date.After(result) {
+			result = m.ClosingBalance.Date.Time
+		}
+	}
+	return result
+}
+
+func orEmpty(data *string) string {
+	if data != nil {
+		return *data
+	}
+	return ""
+}
+
+// WriteCamt053 writes MT940 messages as camt.053 document, every message becomes a separate statement.
+// Creation date time is taken from the closing balance date, so the output is reproducible.
+func WriteCamt053(w io.Writer, messages ...grammar.MT940Message) error {
+	if len(messages) == 0 {
+		return fmt.Errorf("no messages to export")
+	}
+	doc := camtDocument{
+		Xmlns: Camt053Namespace,
+		Stmt: camtBkToCstmrStmt{
+			GrpHdr: camtGroupHeader{
+				MsgId:   messages[0].TransactionRefNo,
+				CreDtTm: lastClosingDate(messages).Format(camtDateTime),
+			},
+		},
+	}
+	for _, m := range messages {
+		doc.Stmt.Stmts = append(doc.Stmt.Stmts, camtStatementOf(m))
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode camt.053 document: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
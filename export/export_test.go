@@ -0,0 +1,61 @@
+package export_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/export"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+	"gotest.tools/v3/golden"
+)
+
+func parseMessages(t *testing.T, names ...string) []grammar.MT940Message {
+	t.Helper()
+	p := parser.NewFileParser[grammar.MT940Message]()
+	messages := []grammar.MT940Message{}
+	for _, name := range names {
+		m, err := p.Parse(filepath.Join("..", "parser", "testdata", "mt940", "input", name), false, nil)
+		assert.NoError(t, err)
+		messages = append(messages, *m)
+	}
+	return messages
+}
+
+func TestWriteJournal(t *testing.T) {
+	rules, err := export.LoadJournalRulesFile(filepath.Join("testdata", "rules.json"))
+	assert.NoError(t, err)
+	messages := parseMessages(t, "csob.sta", "mbank.sta")
+	for name, format := range map[string]export.JournalFormat{"journal.beancount": export.Beancount, "journal.ledger": export.Ledger} {
+		sb := &strings.Builder{}
+		assert.NoError(t, export.WriteJournal(sb, format, rules, messages...))
+		golden.Assert(t, sb.String(), name)
+	}
+}
+
+func TestWriteCamt053(t *testing.T) {
+	sb := &strings.Builder{}
+	assert.NoError(t, export.WriteCamt053(sb, parseMessages(t, "csob.sta", "entry-date.sta")...))
+	golden.Assert(t, sb.String(), "camt053.xml")
+}
+
+func TestWriteOFX(t *testing.T) {
+	sb := &strings.Builder{}
+	assert.NoError(t, export.WriteOFX(sb, parseMessages(t, "csob.sta", "entry-date.sta")...))
+	golden.Assert(t, sb.String(), "statement.ofx")
+}
+
+func TestJournalRulesInCode(t *testing.T) {
+	ss := grammar.StatementSection{}
+	rules := &export.JournalRules{Rules: []export.JournalRule{{TransactionCode: "TRF", Account: "Expenses:Transfers"}}}
+	account, err := rules.CounterAccountFor(ss)
+	assert.NoError(t, err)
+	assert.Equal(t, export.DefaultCounterAccount, account)
+
+	rules.Rules = append([]export.JournalRule{{Pattern: "(", Account: "Expenses:Bad"}}, rules.Rules...)
+	_, err = rules.CounterAccountFor(ss)
+	assert.Error(t, err)
+	assert.Error(t, export.WriteJournal(&strings.Builder{}, export.Beancount, rules, parseMessages(t, "csob.sta")...))
+}
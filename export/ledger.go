@@ -0,0 +1,324 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oswida/mt9x/grammar"
+)
+
+const (
+	DefaultBankAccount    = "Assets:Bank"
+	DefaultCounterAccount = "Equity:Uncategorized"
+)
+
+// JournalFormat selects plain-text accounting journal syntax.
+type JournalFormat int
+
+const (
+	Beancount JournalFormat = iota
+	Ledger                  // ledger-cli and hledger
+)
+
+// JournalRule maps matching statement lines to a counter account.
+// Empty criteria match every line, so the rule without criteria can be used as a fallback.
+type JournalRule struct {
+	// Transaction identification code, with (NTRF) or without (TRF) the type letter.
+	TransactionCode string `json:"trx_code,omitempty"`
+	// Debit/credit mark of the statement line (D, C, RD, RC).
+	DCMark string `json:"dc_mark,omitempty"`
+	// Regular expression matched against the joined :86: lines.
+	Pattern string `json:"pattern,omitempty"`
+	// Counter account used for the matching line.
+	Account string `json:"account"`
+
+	re *regexp.Regexp
+}
+
+// JournalRules describes how MT940 messages are mapped to journal accounts.
+type JournalRules struct {
+	// Journal account of the statement account, used when Accounts has no entry.
+	BankAccount string `json:"bank_account,omitempty"`
+	// Journal accounts indexed by the account identification (tag 25).
+	Accounts map[string]string `json:"accounts,omitempty"`
+	// Counter account used when no rule matches.
+	DefaultAccount string `json:"default_account,omitempty"`
+	// Rules checked in order, the first matching one wins.
+	Rules []JournalRule `json:"rules,omitempty"`
+}
+
+// LoadJournalRules reads JSON rule set from reader.
+func LoadJournalRules(r io.Reader) (*JournalRules, error) {
+	rules := &JournalRules{}
+	if err := json.NewDecoder(r).Decode(rules); err != nil {
+		return nil, fmt.Errorf("failed to decode journal rules: %w", err)
+	}
+	if err := rules.Compile(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// LoadJournalRulesFile reads JSON rule set from file.
+func LoadJournalRulesFile(filename string) (*JournalRules, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	return LoadJournalRules(f)
+}
+
+// Compile checks the rules and compiles their patterns. It is called by LoadJournalRules and WriteJournal,
+// rules created in code are compiled on the first match otherwise.
+func (jr *JournalRules) Compile() error {
+	for i := range jr.Rules {
+		if jr.Rules[i].Account == "" {
+			return fmt.Errorf("journal rule %d has no account", i)
+		}
+		if err := jr.Rules[i].compile(); err != nil {
+			return fmt.Errorf("bad pattern in journal rule %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// compile compiles the rule pattern if it is not compiled yet.
+func (r *JournalRule) compile() error {
+	if r.Pattern == "" || r.re != nil {
+		return nil
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return err
+	}
+	r.re = re
+	return nil
+}
+
+// matchesCode checks transaction identification code with (NTRF) or without (TRF) the type letter.
+func matchesCode(code, ti string) bool {
+	return code == ti || (len(ti) > 1 && code == ti[1:])
+}
+
+// matches checks if rule criteria are fulfilled by the statement section.
+func (r *JournalRule) matches(ss grammar.StatementSection) (bool, error) {
+	if r.TransactionCode != "" && !matchesCode(r.TransactionCode, ss.Statement.TransactionIdent) {
+		return false, nil
+	}
+	if r.DCMark != "" && r.DCMark != string(ss.Statement.DCMark) {
+		return false, nil
+	}
+	if err := r.compile(); err != nil {
+		return false, fmt.Errorf("bad pattern in journal rule: %w", err)
+	}
+	if r.re != nil && !r.re.MatchString(strings.Join(ss.AccountOwnerInfo, " ")) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// BankAccountFor returns journal account for the MT account identification.
+func (jr *JournalRules) BankAccountFor(account string) string {
+	if a, ok := jr.Accounts[account]; ok {
+		return a
+	}
+	if jr.BankAccount != "" {
+		return jr.BankAccount
+	}
+	return DefaultBankAccount
+}
+
+// CounterAccountFor returns journal account for the other side of the statement line.
+func (jr *JournalRules) CounterAccountFor(ss grammar.StatementSection) (string, error) {
+	for i := range jr.Rules {
+		ok, err := jr.Rules[i].matches(ss)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return jr.Rules[i].Account, nil
+		}
+	}
+	if jr.DefaultAccount != "" {
+		return jr.DefaultAccount, nil
+	}
+	return DefaultCounterAccount, nil
+}
+
+// ledgerText replaces characters starting comments and breaking lines in ledger payees and comments.
+func ledgerText(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, ";", ",")), " ")
+}
+
+// quote escapes text for beancount string literals.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// narration returns transaction description based on :86: lines or supplementary details.
+func narration(ss grammar.StatementSection) string {
+	if len(ss.AccountOwnerInfo) > 0 {
+		return strings.Join(strings.Fields(strings.Join(ss.AccountOwnerInfo, " ")), " ")
+	}
+	if ss.Statement.Details != nil {
+		return strings.TrimSpace(*ss.Statement.Details)
+	}
+	return ss.Statement.Reference
+}
+
+// WriteJournal writes MT940 messages as plain-text accounting journal.
+// Opening (:60F:) and closing (:62F:) balances are written as balance assertions placed before
+// and after the statement lines, intermediate balances (:60M:, :62M:) are skipped.
+// Transactions are dated with the value date of the statement line. Beancount output starts with open
+// directives of all used accounts, so it can be checked as a standalone file.
+func WriteJournal(w io.Writer, format JournalFormat, rules *JournalRules, messages ...grammar.MT940Message) error {
+	if rules == nil {
+		rules = &JournalRules{}
+	}
+	if err := rules.Compile(); err != nil {
+		return err
+	}
+	var write func(io.Writer, *JournalRules, grammar.MT940Message) error
+	switch format {
+	case Beancount:
+		write = writeBeancount
+	case Ledger:
+		write = writeLedger
+	default:
+		return fmt.Errorf("unknown journal format: %d", format)
+	}
+	if format == Beancount && len(messages) > 0 {
+		if err := writeBeancountOpen(w, rules, messages); err != nil {
+			return err
+		}
+	}
+	for i, m := range messages {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := write(w, rules, m); err != nil {
+			return fmt.Errorf("failed to write message %s: %w", m.TransactionRefNo, err)
+		}
+	}
+
+	return nil
+}
+
+// writeBeancountOpen writes open directives of bank and counter accounts dated with the earliest date
+// of all messages, accounts are written in order of their first use.
+func writeBeancountOpen(w io.Writer, rules *JournalRules, messages []grammar.MT940Message) error {
+	accounts := []string{}
+	var earliest time.Time
+	use := func(account string, date time.Time) {
+		if !slices.Contains(accounts, account) {
+			accounts = append(accounts, account)
+		}
+		if earliest.IsZero() || date.Before(earliest) {
+			earliest = date
+		}
+	}
+	for _, m := range messages {
+		bank := rules.BankAccountFor(m.AccountIdentification.Account)
+		use(bank, m.OpeningBalance.Date.Time)
+		for _, ss := range m.Statements {
+			counter, err := rules.CounterAccountFor(ss)
+			if err != nil {
+				return fmt.Errorf("failed to write message %s: %w", m.TransactionRefNo, err)
+			}
+			use(bank, ss.Statement.ValueDate.Time)
+			use(counter, ss.Statement.ValueDate.Time)
+		}
+	}
+	lines := []string{}
+	for _, account := range accounts {
+		lines = append(lines, fmt.Sprintf("%s open %s\n", earliest.Format(time.DateOnly), account))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "")+"\n")
+	return err
+}
+
+func writeBeancount(w io.Writer, rules *JournalRules, m grammar.MT940Message) error {
+	bank := rules.BankAccountFor(m.AccountIdentification.Account)
+	currency := m.OpeningBalance.Currency
+	lines := []string{}
+	// Beancount checks balance at the beginning of the day, so the closing assertion is moved to the next day.
+	if !m.IntermediateOpening {
+		lines = append(lines, fmt.Sprintf("%s balance %s %s %s\n",
+			m.OpeningBalance.Date.Format(time.DateOnly), bank,
+			m.OpeningBalance.SignedAmount().StringFixed(2), currency))
+	}
+	for _, ss := range m.Statements {
+		s := ss.Statement
+		tx := []string{fmt.Sprintf("%s * %s", s.ValueDate.Format(time.DateOnly), quote(narration(ss)))}
+		if ref := strings.TrimSpace(s.Reference); ref != "" {
+			tx = append(tx, "  owner_ref: "+quote(ref))
+		}
+		if s.InstitutionReference != nil && *s.InstitutionReference != "" {
+			tx = append(tx, "  institution_ref: "+quote(*s.InstitutionReference))
+		}
+		tx = append(tx, "  trx_ident: "+quote(s.TransactionIdent))
+		tx = append(tx, fmt.Sprintf("  %s  %s %s", bank, s.SignedAmount().StringFixed(2), currency))
+		counter, err := rules.CounterAccountFor(ss)
+		if err != nil {
+			return err
+		}
+		tx = append(tx, "  "+counter)
+		lines = append(lines, strings.Join(tx, "\n")+"\n")
+	}
+	if !m.IntermediateClosing {
+		lines = append(lines, fmt.Sprintf("%s balance %s %s %s\n",
+			m.ClosingBalance.Date.AddDate(0, 0, 1).Format(time.DateOnly), bank,
+			m.ClosingBalance.SignedAmount().StringFixed(2), m.ClosingBalance.Currency))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func writeLedger(w io.Writer, rules *JournalRules, m grammar.MT940Message) error {
+	bank := rules.BankAccountFor(m.AccountIdentification.Account)
+	currency := m.OpeningBalance.Currency
+	lines := []string{}
+	if !m.IntermediateOpening {
+		lines = append(lines, fmt.Sprintf("%s * Opening balance\n    %s  0 %s = %s %s\n",
+			m.OpeningBalance.Date.Format(time.DateOnly), bank, currency,
+			m.OpeningBalance.SignedAmount().StringFixed(2), currency))
+	}
+	for _, ss := range m.Statements {
+		s := ss.Statement
+		tx := []string{fmt.Sprintf("%s * %s", s.ValueDate.Format(time.DateOnly), ledgerText(narration(ss)))}
+		if ref := strings.TrimSpace(s.Reference); ref != "" {
+			tx = append(tx, "    ; owner_ref: "+ledgerText(ref))
+		}
+		if s.InstitutionReference != nil && *s.InstitutionReference != "" {
+			tx = append(tx, "    ; institution_ref: "+ledgerText(*s.InstitutionReference))
+		}
+		tx = append(tx, "    ; trx_ident: "+s.TransactionIdent)
+		tx = append(tx, fmt.Sprintf("    %s  %s %s", bank, s.SignedAmount().StringFixed(2), currency))
+		counter, err := rules.CounterAccountFor(ss)
+		if err != nil {
+			return err
+		}
+		tx = append(tx, "    "+counter)
+		lines = append(lines, strings.Join(tx, "\n")+"\n")
+	}
+	if !m.IntermediateClosing {
+		lines = append(lines, fmt.Sprintf("%s * Closing balance\n    %s  0 %s = %s %s\n",
+			m.ClosingBalance.Date.Format(time.DateOnly), bank, m.ClosingBalance.Currency,
+			m.ClosingBalance.SignedAmount().StringFixed(2), m.ClosingBalance.Currency))
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/oswida/mt9x/grammar"
+)
+
+// Open Financial Exchange 2.2 bank statement response.
+
+const (
+	ofxHeader   = `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxDate     = "20060102"
+	ofxMaxName  = 32
+	ofxMaxMemo  = 255
+	ofxMaxFitID = 255
+)
+
+type ofxDocument struct {
+	XMLName xml.Name       `xml:"OFX"`
+	SignOn  ofxSignOn      `xml:"SIGNONMSGSRSV1>SONRS"`
+	Stmts   []ofxStmtTrnRs `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStmtTrnRs struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs ofxStmtRs `xml:"STMTRS"`
+}
+
+type ofxStmtRs struct {
+	CurDef    string           `xml:"CURDEF"`
+	BankID    string           `xml:"BANKACCTFROM>BANKID"`
+	AcctID    string           `xml:"BANKACCTFROM>ACCTID"`
+	AcctType  string           `xml:"BANKACCTFROM>ACCTTYPE"`
+	DTStart   string           `xml:"BANKTRANLIST>DTSTART"`
+	DTEnd     string           `xml:"BANKTRANLIST>DTEND"`
+	Trns      []ofxTransaction `xml:"BANKTRANLIST>STMTTRN"`
+	LedgerBal ofxBalance       `xml:"LEDGERBAL"`
+	AvailBal  *ofxBalance      `xml:"AVAILBAL,omitempty"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	DTUser   string `xml:"DTUSER"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FitID    string `xml:"FITID"`
+	RefNum   string `xml:"REFNUM,omitempty"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// ofxBalanceOf converts balance to OFX one.
+func ofxBalanceOf(b grammar.Balance) ofxBalance {
+	return ofxBalance{
+		BalAmt: b.SignedAmount().StringFixed(2),
+		DTAsOf: b.Date.Format(ofxDate),
+	}
+}
+
+// ofxStatementOf converts MT940 message to OFX statement response.
+func ofxStatementOf(m grammar.MT940Message) ofxStmtTrnRs {
+	rs := ofxStmtRs{
+		CurDef:    m.OpeningBalance.Currency,
+		BankID:    orEmpty(m.AccountIdentification.IdentCode),
+		AcctID:    m.AccountIdentification.Account,
+		AcctType:  "CHECKING",
+		DTStart:   m.OpeningBalance.Date.Format(ofxDate),
+		DTEnd:     m.ClosingBalance.Date.Format(ofxDate),
+		LedgerBal: ofxBalanceOf(m.ClosingBalance),
+	}
+	if m.ClosingAvailableBalance != nil {
+		bal := ofxBalanceOf(*m.ClosingAvailableBalance)
+		rs.AvailBal = &bal
+	}
+	for i, ss := range m.Statements {
+		s := ss.Statement
+		amount := s.SignedAmount()
+		trn := ofxTransaction{
+			TrnType:  "CREDIT",
+			DTPosted: s.BookingDate().Format(ofxDate),
+			DTUser:   s.ValueDate.Format(ofxDate),
+			TrnAmt:   amount.StringFixed(2),
+			FitID:    orEmpty(s.InstitutionReference),
+			RefNum:   truncate(strings.TrimSpace(s.Reference), ofxMaxName),
+			Memo:     truncate(narration(ss), ofxMaxMemo),
+		}
+		if amount.IsNegative() {
+			trn.TrnType = "DEBIT"
+		}
+		if trn.FitID == "" {
+			// Institution reference is optional, so line position is used to build unique identifier.
+			trn.FitID = m.TransactionRefNo + "/" + m.StatementNumber.StatementNo + "/" + strconv.Itoa(i+1)
+		}
+		trn.FitID = truncate(trn.FitID, ofxMaxFitID)
+		if ss.Statement.Details != nil {
+			trn.Name = truncate(*ss.Statement.Details, ofxMaxName)
+		}
+		rs.Trns = append(rs.Trns, trn)
+	}
+
+	return ofxStmtTrnRs{
+		TrnUID: m.TransactionRefNo,
+		Status: ofxStatus{Code: 0, Severity: "INFO"},
+		StmtRs: rs,
+	}
+}
+
+// WriteOFX writes MT940 messages as OFX document, every message becomes a separate statement response.
+// Server date is taken from the closing balance date, so the output is reproducible.
+func WriteOFX(w io.Writer, messages ...grammar.MT940Message) error {
+	if len(messages) == 0 {
+		return fmt.Errorf("no messages to export")
+	}
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			DTServer: lastClosingDate(messages).Format(ofxDate),
+			Language: "ENG",
+		},
+	}
+	for _, m := range messages {
+		doc.Stmts = append(doc.Stmts, ofxStatementOf(m))
+	}
+	if _, err := io.WriteString(w, xml.Header+ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", " ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode OFX document: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,186 @@
+package grammar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// AssembledStatement is a logical statement built from messages sharing account and statement number.
+type AssembledStatement struct {
+	Account     string `json:"account"`
+	StatementNo string `json:"stmt_number"`
+	// Messages of the statement ordered by sequence number.
+	Parts []MT940Message `json:"parts"`
+	// Sequence numbers of parts which are not present, a missing last part is reported
+	// as the number following the last received one.
+	MissingParts []int `json:"missing_parts,omitempty"`
+	// Sequence numbers of parts received more than once.
+	DuplicateParts []int `json:"duplicate_parts,omitempty"`
+	// Problems found during assembly, e.g. not matching intermediate balances.
+	Errors []string `json:"errors,omitempty"`
+	// Single message with the opening balance of the first part, statement lines of all parts
+	// and closing balances of the last part.
+	Statement MT940Message `json:"statement"`
+}
+
+// Complete reports whether all parts are present only once and intermediate balances match.
+func (s AssembledStatement) Complete() bool {
+	return !s.Incomplete() && len(s.DuplicateParts) == 0
+}
+
+// Incomplete reports whether parts are missing or intermediate balances do not match,
+// parts received more than once do not make the statement incomplete.
+func (s AssembledStatement) Incomplete() bool {
+	return len(s.MissingParts) > 0 || len(s.Errors) > 0
+}
+
+// Validate returns error describing the first problem of the assembled statement.
+func (s AssembledStatement) Validate() error {
+	if err := s.incompleteError(); err != nil {
+		return err
+	}
+	if len(s.DuplicateParts) > 0 {
+		return fmt.Errorf("statement %s of account %s: duplicated parts %s", s.StatementNo, s.Account, joinParts(s.DuplicateParts))
+	}
+	return nil
+}
+
+// incompleteError returns error describing missing parts or the first not matching balance.
+func (s AssembledStatement) incompleteError() error {
+	if len(s.MissingParts) > 0 {
+		return fmt.Errorf("statement %s of account %s: missing parts %s", s.StatementNo, s.Account, joinParts(s.MissingParts))
+	}
+	if len(s.Errors) > 0 {
+		return fmt.Errorf("statement %s of account %s: %s", s.StatementNo, s.Account, s.Errors[0])
+	}
+	return nil
+}
+
+func joinParts(parts []int) string {
+	result := make([]string, len(parts))
+	for i, p := range parts {
+		result[i] = strconv.Itoa(p)
+	}
+	return strings.Join(result, ", ")
+}
+
+// sequenceNumber returns message sequence number, messages without it are the only part of the statement.
+func sequenceNumber(m MT940Message) (int, error) {
+	if m.StatementNumber.SequenceNo == nil {
+		return 1, nil
+	}
+	return strconv.Atoi(*m.StatementNumber.SequenceNo)
+}
+
+// AssembleStatements groups messages by account and statement number, orders them by sequence number
+// and merges every group into one logical statement. Statement numbers are compared as numbers, e.g. 29 and 00029
+// are the same statement, which has the number given in its first message. Statements are returned in order
+// of their first message.
+func AssembleStatements(messages ...MT940Message) ([]AssembledStatement, error) {
+	type key struct {
+		account   string
+		statement int
+	}
+	groups := map[key][]MT940Message{}
+	order := []key{}
+	for i, m := range messages {
+		if seq, err := sequenceNumber(m); err != nil {
+			return nil, fmt.Errorf("bad sequence number of message %d: %w", i+1, err)
+		} else if seq < 1 {
+			return nil, fmt.Errorf("bad sequence number of message %d: %d is lower than 1", i+1, seq)
+		}
+		number, err := strconv.Atoi(m.StatementNumber.StatementNo)
+		if err != nil {
+			return nil, fmt.Errorf("bad statement number of message %d: %w", i+1, err)
+		}
+		k := key{m.AccountIdentification.Account, number}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], m)
+	}
+	result := make([]AssembledStatement, len(order))
+	for i, k := range order {
+		result[i] = assemble(k.account, groups[k][0].StatementNumber.StatementNo, groups[k])
+	}
+	return result, nil
+}
+
+func assemble(account, statementNo string, parts []MT940Message) AssembledStatement {
+	sort.SliceStable(parts, func(i, j int) bool {
+		a, _ := sequenceNumber(parts[i])
+		b, _ := sequenceNumber(parts[j])
+		return a < b
+	})
+	result := AssembledStatement{Account: account, StatementNo: statementNo, Parts: parts}
+	expected := 1
+	// Parts without duplicates, only the first message of every sequence number is used.
+	unique := []MT940Message{}
+	for _, p := range parts {
+		seq, _ := sequenceNumber(p)
+		if seq < expected {
+			result.DuplicateParts = append(result.DuplicateParts, seq)
+			continue
+		}
+		for ; expected < seq; expected++ {
+			result.MissingParts = append(result.MissingParts, expected)
+		}
+		expected++
+		unique = append(unique, p)
+		if len(unique) == 1 {
+			continue
+		}
+		// Balances can be compared only with the directly preceding part.
+		prev := unique[len(unique)-2]
+		if prevSeq, _ := sequenceNumber(prev); prevSeq != seq-1 {
+			continue
+		}
+		if !prev.IntermediateClosing {
+			result.Errors = append(result.Errors, fmt.Sprintf("part %d follows final closing balance", seq))
+		}
+		if !p.IntermediateOpening {
+			result.Errors = append(result.Errors, fmt.Sprintf("part %d has final opening balance", seq))
+		}
+		if !balancesEqual(prev.ClosingBalance, p.OpeningBalance) {
+			result.Errors = append(result.Errors, fmt.Sprintf("opening balance of part %d differs from closing balance of the previous part", seq))
+		}
+	}
+	if len(unique) == 0 {
+		result.Errors = append(result.Errors, "no valid parts")
+		result.Statement = parts[0]
+		return result
+	}
+	first, last := unique[0], unique[len(unique)-1]
+	if seq, _ := sequenceNumber(first); seq == 1 && first.IntermediateOpening {
+		result.Errors = append(result.Errors, "first part has intermediate opening balance")
+	}
+	if last.IntermediateClosing {
+		result.MissingParts = append(result.MissingParts, expected)
+	}
+
+	statement := first
+	statement.StatementNumber = StatementNumber{StatementNo: statementNo}
+	statement.Statements = nil
+	statement.Extensions = nil
+	for _, p := range unique {
+		statement.Statements = append(statement.Statements, p.Statements...)
+		statement.Extensions = append(statement.Extensions, p.Extensions...)
+	}
+	statement.IntermediateClosing = last.IntermediateClosing
+	statement.ClosingBalance = last.ClosingBalance
+	statement.ClosingAvailableBalance = last.ClosingAvailableBalance
+	statement.ForwardAvailableBalance = last.ForwardAvailableBalance
+	statement.AccountOwnerInfo = last.AccountOwnerInfo
+	// Statement is not a part of any source text.
+	statement.Source = parser.Source{}
+	result.Statement = statement
+	return result
+}
+
+func balancesEqual(a, b Balance) bool {
+	return a.DCMark == b.DCMark && a.Currency == b.Currency && a.Date.Equal(b.Date.Time) && a.Amount.Equal(b.Amount.Decimal)
+}
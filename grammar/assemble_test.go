@@ -0,0 +1,69 @@
+package grammar_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+)
+
+func TestAssembleStatements(t *testing.T) {
+	part := func(number, seq, opening, lines, closing string) grammar.MT940Message {
+		return statementPart(t, "PL61109010140000071219812874", number, seq, opening, lines, closing)
+	}
+	first := part("29", "001", ":60F:C240603EUR1000,00", ":61:2406030603D100,00NTRFNONREF\r\n", ":62M:C240603EUR900,00")
+	second := part("00029", "002", ":60M:C240603EUR900,00", ":61:2406030603C50,00NTRFNONREF\r\n", ":62M:C240603EUR950,00")
+	third := part("29", "003", ":60M:C240603EUR950,00", ":61:2406030603D25,00NTRFNONREF\r\n", ":62F:C240603EUR925,00")
+	other := part("30", "", ":60F:C240603EUR10,00", "", ":62F:C240603EUR10,00")
+
+	result, err := grammar.AssembleStatements(third, other, first, second)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	s := result[0]
+	assert.True(t, s.Complete())
+	assert.NoError(t, s.Validate())
+	assert.Equal(t, "29", s.StatementNo)
+	assert.Equal(t, []string{"STMT001", "STMT002", "STMT003"}, []string{s.Parts[0].TransactionRefNo, s.Parts[1].TransactionRefNo, s.Parts[2].TransactionRefNo})
+	assert.Equal(t, 3, len(s.Statement.Statements))
+	assert.Equal(t, "1000", s.Statement.OpeningBalance.Amount.String())
+	assert.Equal(t, "925", s.Statement.ClosingBalance.Amount.String())
+	assert.False(t, s.Statement.IntermediateOpening || s.Statement.IntermediateClosing)
+	assert.Zero(t, s.Statement.StatementNumber.SequenceNo)
+	assert.NoError(t, s.Statement.Validate())
+	assert.True(t, result[1].Complete())
+
+	second.OpeningBalance.Amount = third.OpeningBalance.Amount
+	result, err = grammar.AssembleStatements(first, second)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3}, result[0].MissingParts)
+	assert.Equal(t, []string{"opening balance of part 2 differs from closing balance of the previous part"}, result[0].Errors)
+	assert.EqualError(t, result[0].Validate(), "statement 29 of account PL61109010140000071219812874: missing parts 3")
+
+	result, err = grammar.AssembleStatements(third, first, first)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result))
+	assert.Equal(t, []int{2}, result[0].MissingParts)
+	assert.Equal(t, []int{1}, result[0].DuplicateParts)
+	assert.Zero(t, result[0].Errors)
+	assert.Equal(t, 3, len(result[0].Parts))
+	assert.Equal(t, 2, len(result[0].Statement.Statements))
+
+	// Statement with duplicated parts is not complete, although no part is missing.
+	second = part("29", "002", ":60M:C240603EUR900,00", ":61:2406030603C50,00NTRFNONREF\r\n", ":62M:C240603EUR950,00")
+	result, err = grammar.AssembleStatements(first, second, second, third)
+	assert.NoError(t, err)
+	assert.Zero(t, result[0].MissingParts)
+	assert.Equal(t, []int{2}, result[0].DuplicateParts)
+	assert.False(t, result[0].Incomplete())
+	assert.False(t, result[0].Complete())
+	assert.EqualError(t, result[0].Validate(), "statement 29 of account PL61109010140000071219812874: duplicated parts 2")
+
+	other.StatementNumber.StatementNo = "3O"
+	_, err = grammar.AssembleStatements(first, other)
+	assert.EqualError(t, err, `bad statement number of message 2: strconv.Atoi: parsing "3O": invalid syntax`)
+
+	// Sequence numbers start at 1.
+	zero := part("29", "00000", ":60F:C240603EUR1000,00", "", ":62F:C240603EUR1000,00")
+	_, err = grammar.AssembleStatements(zero)
+	assert.EqualError(t, err, "bad sequence number of message 1: 0 is lower than 1")
+}
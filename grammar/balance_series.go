@@ -0,0 +1,175 @@
+package grammar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BalanceSeriesCSVHeader = `Account,Currency,Date,Booked,Credits,Debits,Count,Available,Forward`
+)
+
+// DateBasis selects the transaction date used for daily balances.
+type DateBasis int
+
+const (
+	// Transactions are accumulated by entry date (value date if entry date is missing).
+	EntryDateBasis DateBasis = iota
+	// Transactions are accumulated by value date.
+	ValueDateBasis
+)
+
+// RunningBalance is a transaction with the balance of the account after it.
+type RunningBalance struct {
+	Transaction
+	Balance decimal.Decimal `json:"balance"`
+}
+
+// BalancePoint is a balance of the account at the end of a day.
+type BalancePoint struct {
+	Account  string    `json:"account"`
+	Currency string    `json:"currency"`
+	Date     time.Time `json:"date"`
+	// Opening balance with signed amounts of transactions up to the date.
+	Booked  decimal.Decimal `json:"booked"`
+	Credits decimal.Decimal `json:"credits"`
+	Debits  decimal.Decimal `json:"debits"`
+	// Number of transactions of the day.
+	Count int `json:"count"`
+	// Closing available (field 64) or forward available (field 65) balance for the date.
+	Available *decimal.Decimal `json:"available,omitempty"`
+	// Set when available balance is a forward one.
+	Forward bool `json:"forward,omitempty"`
+	// Set when booked balance is computed for the date.
+	booked bool
+}
+
+// RunningBalances returns transactions of the messages with balances after each of them,
+// starting from the opening balance of every message.
+func RunningBalances(messages ...MT940Message) []RunningBalance {
+	result := []RunningBalance{}
+	for _, m := range messages {
+		balance := m.OpeningBalance.SignedAmount()
+		for _, t := range m.Transactions() {
+			balance = balance.Add(t.SignedAmount)
+			result = append(result, RunningBalance{Transaction: t, Balance: balance})
+		}
+	}
+	return result
+}
+
+// BalanceSeries returns end of day balances of accounts, ordered by first occurrence of the account and date.
+// Every message starts from its opening balance, so the balance of a day present in more messages is taken
+// from the last one. Days with available balances only carry the booked balance of the previous day.
+func BalanceSeries(basis DateBasis, messages ...MT940Message) []BalancePoint {
+	series := map[string]map[time.Time]*BalancePoint{}
+	accounts := []string{}
+	for _, m := range messages {
+		account := m.AccountIdentification.Account
+		points, ok := series[account]
+		if !ok {
+			points = map[time.Time]*BalancePoint{}
+			series[account] = points
+			accounts = append(accounts, account)
+		}
+		// Points are recomputed from the opening balance of the message.
+		fresh := map[time.Time]*BalancePoint{}
+		point := func(date time.Time) *BalancePoint {
+			p, ok := fresh[date]
+			if !ok {
+				p = &BalancePoint{Account: account, Currency: m.OpeningBalance.Currency, Date: date}
+				if old, ok := points[date]; ok {
+					p.Available, p.Forward = old.Available, old.Forward
+				}
+				fresh[date] = p
+			}
+			return p
+		}
+		transactions := m.Transactions()
+		sort.SliceStable(transactions, func(i, j int) bool {
+			return transactionDate(basis, transactions[i]).Before(transactionDate(basis, transactions[j]))
+		})
+		balance := m.OpeningBalance.SignedAmount()
+		point(m.OpeningBalance.Date.Time).setBooked(balance)
+		for _, t := range transactions {
+			balance = balance.Add(t.SignedAmount)
+			p := point(transactionDate(basis, t))
+			p.setBooked(balance)
+			p.Count++
+			if t.SignedAmount.IsNegative() {
+				p.Debits = p.Debits.Add(t.SignedAmount.Neg())
+			} else {
+				p.Credits = p.Credits.Add(t.SignedAmount)
+			}
+		}
+		if _, ok := fresh[m.ClosingBalance.Date.Time]; !ok {
+			point(m.ClosingBalance.Date.Time).setBooked(m.ClosingBalance.SignedAmount())
+		}
+		available := []Balance{}
+		if m.ClosingAvailableBalance != nil {
+			available = append(available, *m.ClosingAvailableBalance)
+		}
+		for i, b := range append(available, m.ForwardAvailableBalance...) {
+			amount := b.SignedAmount()
+			p := point(b.Date.Time)
+			p.Available, p.Forward = &amount, i >= len(available)
+		}
+		for date, p := range fresh {
+			points[date] = p
+		}
+	}
+	result := []BalancePoint{}
+	for _, account := range accounts {
+		points := []BalancePoint{}
+		for _, p := range series[account] {
+			points = append(points, *p)
+		}
+		sort.Slice(points, func(i, j int) bool { return points[i].Date.Before(points[j].Date) })
+		for i := range points {
+			// Days with available balances only have no booked balance computed.
+			if i > 0 && !points[i].booked {
+				points[i].Booked = points[i-1].Booked
+			}
+		}
+		result = append(result, points...)
+	}
+	return result
+}
+
+func (p *BalancePoint) setBooked(balance decimal.Decimal) {
+	p.Booked, p.booked = balance, true
+}
+
+func transactionDate(basis DateBasis, t Transaction) time.Time {
+	if basis == ValueDateBasis {
+		return t.ValueDate
+	}
+	return t.BookingDate
+}
+
+// BalanceSeriesToCSV converts balance points to CSV rows with the header.
+func BalanceSeriesToCSV(points []BalancePoint) []string {
+	rows := []string{BalanceSeriesCSVHeader}
+	for _, p := range points {
+		available := ""
+		if p.Available != nil {
+			available = p.Available.StringFixed(2)
+		}
+		rows = append(rows, strings.Join([]string{
+			p.Account,
+			p.Currency,
+			p.Date.Format(time.DateOnly),
+			p.Booked.StringFixed(2),
+			p.Credits.StringFixed(2),
+			p.Debits.StringFixed(2),
+			strconv.Itoa(p.Count),
+			available,
+			strconv.FormatBool(p.Forward),
+		}, ","))
+	}
+	return rows
+}
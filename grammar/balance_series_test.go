@@ -0,0 +1,35 @@
+package grammar_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestBalanceSeries(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt940", "input", "entry-date.sta"), false, nil)
+	assert.NoError(t, err)
+	running := grammar.RunningBalances(*m)
+	assert.Equal(t, 1, len(running))
+	assert.Equal(t, "441112311.71", running[0].Balance.String())
+
+	assert.Equal(t, []string{
+		grammar.BalanceSeriesCSVHeader,
+		"123-304958,USD,2009-01-24,441112311.71,0.00,10000000.00,1,435212311.71,false",
+		"123-304958,USD,2009-01-26,441112311.71,0.00,0.00,0,440912311.71,true",
+		"123-304958,USD,2009-01-27,441112311.71,0.00,0.00,0,441112311.71,true",
+	}, grammar.BalanceSeriesToCSV(grammar.BalanceSeries(grammar.ValueDateBasis, *m)))
+
+	// Entry date of the transaction is one month after the value date.
+	points := grammar.BalanceSeries(grammar.EntryDateBasis, *m)
+	assert.Equal(t, 4, len(points))
+	assert.Equal(t, "451112311.71", points[0].Booked.String())
+	assert.Equal(t, "2009-02-24", points[3].Date.Format(time.DateOnly))
+	assert.Equal(t, "441112311.71", points[3].Booked.String())
+	assert.Equal(t, 1, points[3].Count)
+}
@@ -0,0 +1,230 @@
+package grammar
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// MessageParser parses message of a single type, the result is a message value, e.g. MT940Message.
+type MessageParser func(data []byte, validate bool, traceWriter io.Writer) (parser.MT9xMessage, error)
+
+// newMessageParser creates parser for the message type, participle parser is built on the first use.
+func newMessageParser[T parser.MT9xMessage]() MessageParser {
+	bp := sync.OnceValue(parser.NewByteParser[T])
+	return func(data []byte, validate bool, traceWriter io.Writer) (parser.MT9xMessage, error) {
+		m, err := bp().Parse(data, validate, traceWriter)
+		if err != nil {
+			return nil, err
+		}
+		return *m, nil
+	}
+}
+
+// messageParsers contains parsers of all supported message types indexed by the type number.
+var messageParsers = map[string]MessageParser{
+	"900": newMessageParser[MT900Message](),
+	"910": newMessageParser[MT910Message](),
+	"920": newMessageParser[MT920Message](),
+	"940": newMessageParser[MT940Message](),
+	"941": newMessageParser[MT941Message](),
+	"942": newMessageParser[MT942Message](),
+	"950": newMessageParser[MT950Message](),
+	"970": newMessageParser[MT970Message](),
+	"971": newMessageParser[MT971Message](),
+	"972": newMessageParser[MT972Message](),
+	"973": newMessageParser[MT973Message](),
+}
+
+// SupportedMessageTypes returns sorted numbers of the message types which can be parsed by ParseMessage.
+func SupportedMessageTypes() []string {
+	result := []string{}
+	for k := range messageParsers {
+		result = append(result, k)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// ParseMessage detects message type and parses the message with the parser of that type.
+// The result is a message value, e.g. MT940Message, and the type can be checked with the type switch
+// or MessageType method.
+func ParseMessage(data []byte, validate bool, traceWriter io.Writer) (parser.MT9xMessage, error) {
+	data, err := parser.Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bytes: %w", err)
+	}
+	messageType, err := DetectMessageType(data)
+	if err != nil {
+		return nil, err
+	}
+	parse, ok := messageParsers[messageType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported message type: %s", messageType)
+	}
+
+	return parse(data, validate, traceWriter)
+}
+
+var (
+	fieldTag = regexp.MustCompile(`(?m)^:([0-9]{2}[A-Z]?):(.*?)\r?$`)
+	// Party field tags with option letter, e.g. :52A: and :52D:, are matched as 52a.
+	partyTag = regexp.MustCompile(`^(5[026])[A-Z]$`)
+)
+
+// messageSignature describes fields of the message type used to detect the type of messages without FIN header.
+type messageSignature struct {
+	messageType string
+	// Every group must have at least one field present.
+	required [][]string
+	// Standard fields of the message type.
+	allowed []string
+	// Checks field values, e.g. requested message types, it is optional.
+	accepts func(values map[string][]string) bool
+}
+
+// requests checks if all message types requested in fields 12 are in the list.
+func requests(types []string) func(values map[string][]string) bool {
+	return func(values map[string][]string) bool {
+		for _, r := range values["12"] {
+			if !slices.Contains(types, r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// statementFields are fields of MT950, MT970 and MT972 statements, MT940 and MT950 allow additional ones.
+var (
+	statementRequired = [][]string{{"20"}, {"25"}, {"28C"}, {"60F", "60M"}, {"62F", "62M"}}
+	statementFields   = []string{"20", "25", "28C", "60F", "60M", "61", "62F", "62M", "64"}
+)
+
+// statementPreference orders statement types which fit the same fields equally well, MT940 is the most common
+// customer statement and its grammar accepts fields of the other types.
+var statementPreference = []string{"940", "950", "970", "972"}
+
+// messageSignatures contains signatures of all supported message types, sorted by the type number.
+var messageSignatures = []messageSignature{
+	{
+		messageType: "900",
+		required:    [][]string{{"20"}, {"21"}, {"25", "25P"}, {"32A"}},
+		allowed:     []string{"20", "21", "25", "25P", "13D", "32A", "52a", "72"},
+	},
+	{
+		// C1: either field 50a or field 52a must be present.
+		messageType: "910",
+		required:    [][]string{{"20"}, {"21"}, {"25", "25P"}, {"32A"}, {"50a", "52a"}},
+		allowed:     []string{"20", "21", "25", "25P", "13D", "32A", "50a", "52a", "56a", "72"},
+	},
+	{
+		messageType: "920",
+		required:    [][]string{{"20"}, {"12"}, {"25"}},
+		allowed:     []string{"20", "12", "25", "34F"},
+		accepts:     requests(requestableTypes),
+	},
+	{
+		messageType: "940",
+		required:    [][]string{{"20"}, {"25", "25P"}, {"28C"}, {"60F", "60M"}, {"62F", "62M"}},
+		allowed:     append([]string{"21", "25P", "65", "86"}, statementFields...),
+	},
+	{
+		messageType: "941",
+		required:    [][]string{{"20"}, {"25", "25P"}, {"28"}, {"62F"}},
+		allowed:     []string{"20", "21", "25", "25P", "28", "13D", "60F", "90D", "90C", "62F", "64", "65", "86"},
+	},
+	{
+		messageType: "942",
+		required:    [][]string{{"20"}, {"25", "25P"}, {"28C"}, {"34F"}, {"13D"}},
+		allowed:     []string{"20", "21", "25", "25P", "28C", "34F", "13D", "61", "86", "90D", "90C"},
+	},
+	{
+		messageType: "950",
+		required:    statementRequired,
+		allowed:     append([]string{"65"}, statementFields...),
+	},
+	{messageType: "970", required: statementRequired, allowed: statementFields},
+	{
+		messageType: "971",
+		required:    [][]string{{"20"}, {"25"}, {"62F"}},
+		allowed:     []string{"20", "25", "62F"},
+	},
+	{messageType: "972", required: statementRequired, allowed: statementFields},
+	{
+		messageType: "973",
+		required:    [][]string{{"20"}, {"12"}, {"25"}},
+		allowed:     []string{"20", "12", "25"},
+		accepts:     requests(nettingRequestableTypes),
+	},
+}
+
+// DetectMessageType returns message type number from the FIN application header (block 2). When the message
+// has no FIN header, the type is detected from the fields present in the message. Message types with all
+// required fields present are candidates, the one with the least fields not defined for the type wins,
+// so non-standard fields do not prevent detection. Statement types fitting equally well are ordered
+// by preference, e.g. MT940 is chosen over MT950 for statements without fields 21, 25P and 86. Error is returned
+// when more than one type fits the fields equally well and they cannot be ordered, e.g. MT900 and MT910
+// confirmations with field 52a only.
+func DetectMessageType(data []byte) (string, error) {
+	messageType, text, err := parser.UnwrapFIN(data)
+	if err != nil {
+		return "", err
+	}
+	if messageType != "" {
+		return messageType, nil
+	}
+
+	values := map[string][]string{}
+	for _, m := range fieldTag.FindAllSubmatch(text, -1) {
+		tag := partyTag.ReplaceAllString(string(m[1]), "${1}a")
+		values[tag] = append(values[tag], string(m[2]))
+	}
+
+	best := []string{}
+	bestUnknown := 0
+	for _, s := range messageSignatures {
+		if !s.matches(values) {
+			continue
+		}
+		unknown := 0
+		for tag := range values {
+			if !slices.Contains(s.allowed, tag) {
+				unknown++
+			}
+		}
+		if len(best) == 0 || unknown < bestUnknown {
+			best, bestUnknown = []string{s.messageType}, unknown
+		} else if unknown == bestUnknown {
+			best = append(best, s.messageType)
+		}
+	}
+
+	switch len(best) {
+	case 0:
+		return "", fmt.Errorf("cannot detect message type")
+	case 1:
+		return best[0], nil
+	}
+	if !slices.ContainsFunc(best, func(t string) bool { return !slices.Contains(statementPreference, t) }) {
+		return slices.MinFunc(best, func(a, b string) int {
+			return slices.Index(statementPreference, a) - slices.Index(statementPreference, b)
+		}), nil
+	}
+	return "", fmt.Errorf("ambiguous message type without FIN header, it can be one of: %s", strings.Join(best, ", "))
+}
+
+// matches checks if required fields are present and field values are accepted.
+func (s messageSignature) matches(values map[string][]string) bool {
+	for _, group := range s.required {
+		if !slices.ContainsFunc(group, func(tag string) bool { return len(values[tag]) > 0 }) {
+			return false
+		}
+	}
+	return s.accepts == nil || s.accepts(values)
+}
@@ -0,0 +1,79 @@
+package grammar_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+)
+
+func TestDetectMessageType(t *testing.T) {
+	tests := []struct {
+		file     string
+		text     string
+		expected string
+		err      string
+	}{
+		{file: "mt900/input/spec-example.sta", err: "ambiguous message type without FIN header, it can be one of: 900, 910"},
+		{text: ":20:C11126A1378\r\n:21:5482ABC\r\n:25:9-9876543\r\n:32A:090123USD233530,\r\n", expected: "900"},
+		{file: "mt910/input/spec-example.sta", expected: "910"},
+		{file: "mt910/input/customer.sta", expected: "910"},
+		{file: "mt920/input/spec-example.sta", expected: "920"},
+		{file: "mt940/input/mbank.sta", expected: "940"},
+		{file: "mt940/input/extensions.sta", expected: "940"},
+		{file: "mt941/input/spec-example.sta", expected: "941"},
+		{file: "mt942/input/spec-example.sta", expected: "942"},
+		{file: "mt950/input/nostro.sta", expected: "940"},
+		{file: "mt950/input/fin-envelope.sta", expected: "950"},
+		{file: "mt970/input/netting.sta", expected: "940"},
+		{text: ":20:REF\r\n:25:123\r\n:28C:1\r\n:60F:C240603EUR1,\r\n:61:2406030603D1,NTRFNONREF\r\n:62F:C240603EUR0,\r\n", expected: "940"},
+		{file: "mt971/input/balances.sta", expected: "971"},
+		{file: "mt973/input/request.sta", expected: "973"},
+		{text: ":20:REF\r\n:12:971\r\n:25:123\r\n:12:940\r\n:25:123\r\n", err: "cannot detect message type"},
+	}
+	for _, test := range tests {
+		t.Run(test.file+test.text, func(t *testing.T) {
+			data := []byte(test.text)
+			if test.file != "" {
+				var err error
+				data, err = os.ReadFile(filepath.Join("..", "parser", "testdata", test.file))
+				assert.NoError(t, err)
+			}
+			messageType, err := grammar.DetectMessageType(data)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, messageType)
+		})
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "parser", "testdata", "mt950", "input", "fin-envelope.sta"))
+	assert.NoError(t, err)
+	m, err := grammar.ParseMessage(data, true, nil)
+	assert.NoError(t, err)
+	mt950, ok := m.(grammar.MT950Message)
+	assert.True(t, ok)
+	assert.Equal(t, "950", mt950.MessageType())
+
+	m, err = grammar.ParseMessage([]byte(":20:REF\r\n:25:123\r\n:28C:1\r\n:34F:EUR1000,\r\n:13D:2406031200+0100\r\n"), true, nil)
+	assert.NoError(t, err)
+	mt942, ok := m.(grammar.MT942Message)
+	assert.True(t, ok)
+	assert.Equal(t, "1000", mt942.FloorLimits[0].Amount.String())
+
+	// Plain statement without fields 21, 25P and 86 is parsed as MT940.
+	m, err = grammar.ParseMessage([]byte(":20:REF\r\n:25:123\r\n:28C:1\r\n:60F:C240603EUR1,\r\n:62F:C240603EUR1,\r\n"), false, nil)
+	assert.NoError(t, err)
+	_, ok = m.(grammar.MT940Message)
+	assert.True(t, ok)
+
+	m, err = grammar.ParseMessage([]byte(":20:REF\r\n:21:REL\r\n:25:123\r\n:32A:090123USD1,\r\n:52A:BANKDEFF\r\n"), false, nil)
+	assert.Error(t, err)
+	assert.Zero(t, m)
+}
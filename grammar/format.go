@@ -0,0 +1,207 @@
+package grammar
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// FieldError describes a field breaking SWIFT length or repetition constraints.
+type FieldError struct {
+	Tag string `json:"tag"`
+	// Line of the message text, for parsed messages it is the line of the parsed input,
+	// including FIN envelope and non-standard fields.
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field %s in line %d: %s", e.Tag, e.Line, e.Message)
+}
+
+// FormatError contains all field errors of the message.
+type FormatError []FieldError
+
+func (e FormatError) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Maximal number of digits (with the decimal comma) of amounts, 15d.
+const maxAmountLength = 15
+
+// fieldCheck checks content lines of the field and returns problems with indexes of lines they are found in.
+type fieldCheck func(lines []string) []lineProblem
+
+type lineProblem struct {
+	index   int
+	message string
+}
+
+// lines limits number of lines and their length, e.g. 6*65x.
+func lines(count, width int) fieldCheck {
+	return func(content []string) []lineProblem {
+		result := []lineProblem{}
+		if len(content) > count {
+			result = append(result, lineProblem{count, fmt.Sprintf("%d lines exceed maximum of %d", len(content), count)})
+		}
+		for i, l := range content {
+			if len(l) > width {
+				result = append(result, lineProblem{i, fmt.Sprintf("length %d exceeds %d characters", len(l), width)})
+			}
+		}
+		return result
+	}
+}
+
+// pattern checks single line field against the expression, its first group (if any) is an amount.
+func pattern(re *regexp.Regexp, format string) fieldCheck {
+	return func(content []string) []lineProblem {
+		if len(content) > 1 {
+			return []lineProblem{{1, fmt.Sprintf("%d lines exceed maximum of 1", len(content))}}
+		}
+		match := re.FindStringSubmatch(content[0])
+		if match == nil {
+			return []lineProblem{{0, "content does not match format " + format}}
+		}
+		if len(match) > 1 {
+			return checkAmount(0, match[1])
+		}
+		return nil
+	}
+}
+
+func checkAmount(index int, amount string) []lineProblem {
+	if len(amount) > maxAmountLength {
+		return []lineProblem{{index, fmt.Sprintf("amount %s exceeds %d digits", amount, maxAmountLength)}}
+	}
+	return nil
+}
+
+var (
+	balanceFormat      = regexp.MustCompile(`^[DC][0-9]{6}[A-Z]{3}([0-9,]+)$`)
+	statementLineStart = regexp.MustCompile(`^[0-9]{6}(?:[0-9]{4})?R?[DC][A-Z]?([0-9,]+)[SNF][A-Z0-9]{3}(.*)$`)
+	fieldStart         = regexp.MustCompile(`^:([0-9]{2}[A-Z]?):`)
+)
+
+// statementLine checks :61: field: reference 16x, institution reference 16x and supplementary details 34x.
+func statementLine(content []string) []lineProblem {
+	if len(content) > 2 {
+		return []lineProblem{{2, fmt.Sprintf("%d lines exceed maximum of 2", len(content))}}
+	}
+	match := statementLineStart.FindStringSubmatch(content[0])
+	if match == nil {
+		return []lineProblem{{0, "content does not match format 6!n[4!n]2a[1!a]15d1!a3!c16x[//16x]"}}
+	}
+	result := checkAmount(0, match[1])
+	reference, institutionReference, _ := strings.Cut(match[2], "//")
+	if len(reference) > 16 {
+		result = append(result, lineProblem{0, fmt.Sprintf("reference length %d exceeds 16 characters", len(reference))})
+	}
+	if len(institutionReference) > 16 {
+		result = append(result, lineProblem{0, fmt.Sprintf("institution reference length %d exceeds 16 characters", len(institutionReference))})
+	}
+	if len(content) > 1 && len(content[1]) > 34 {
+		result = append(result, lineProblem{1, fmt.Sprintf("supplementary details length %d exceeds 34 characters", len(content[1]))})
+	}
+	return result
+}
+
+// party checks :50a:, :52a: and :56a: fields: optional party identifier [/1!a][/34x] and 4*35x lines.
+func party(content []string) []lineProblem {
+	if strings.HasPrefix(content[0], "/") {
+		result := []lineProblem{}
+		if len(content[0]) > 37 {
+			result = append(result, lineProblem{0, fmt.Sprintf("party identifier length %d exceeds 37 characters", len(content[0]))})
+		}
+		for _, p := range lines(4, 35)(content[1:]) {
+			result = append(result, lineProblem{p.index + 1, p.message})
+		}
+		return result
+	}
+	return lines(4, 35)(content)
+}
+
+// fieldChecks contains constraints of fields by their tags, fields with other tags are not checked.
+var fieldChecks = map[string]fieldCheck{
+	"12":  pattern(regexp.MustCompile(`^[0-9]{3}$`), "3!n"),
+	"13D": pattern(regexp.MustCompile(`^[0-9]{10}[+-][0-9]{4}$`), "6!n4!n1!x4!n"),
+	"20":  lines(1, 16),
+	"21":  lines(1, 16),
+	"25":  lines(1, 35),
+	"25P": lines(2, 35),
+	"28":  pattern(regexp.MustCompile(`^[0-9]{1,5}(?:/[0-9]{1,2})?$`), "5n[/2n]"),
+	"28C": pattern(regexp.MustCompile(`^[0-9]{1,5}(?:/[0-9]{1,5})?$`), "5n[/5n]"),
+	"32A": pattern(regexp.MustCompile(`^[0-9]{6}[A-Z]{3}([0-9,]+)$`), "6!n3!a15d"),
+	"34F": pattern(regexp.MustCompile(`^[A-Z]{3}[DC]?([0-9,]+)$`), "3!a[1!a]15d"),
+	"60F": pattern(balanceFormat, "1!a6!n3!a15d"),
+	"60M": pattern(balanceFormat, "1!a6!n3!a15d"),
+	"62F": pattern(balanceFormat, "1!a6!n3!a15d"),
+	"62M": pattern(balanceFormat, "1!a6!n3!a15d"),
+	"64":  pattern(balanceFormat, "1!a6!n3!a15d"),
+	"65":  pattern(balanceFormat, "1!a6!n3!a15d"),
+	"90C": pattern(regexp.MustCompile(`^[0-9]{1,5}[A-Z]{3}([0-9,]+)$`), "5n3!a15d"),
+	"90D": pattern(regexp.MustCompile(`^[0-9]{1,5}[A-Z]{3}([0-9,]+)$`), "5n3!a15d"),
+	"61":  statementLine,
+	"86":  lines(6, 65),
+	"72":  lines(6, 35),
+	"50A": party, "50F": party, "50K": party,
+	"52A": party, "52D": party,
+	"56A": party, "56D": party,
+}
+
+// CheckFormat checks lengths and numbers of lines of the message text fields.
+// Lines are numbered from firstLine, which is the line of the first field.
+func CheckFormat(text string, firstLine int) FormatError {
+	result := FormatError{}
+	tag, start := "", 0
+	content := []string{}
+	flush := func() {
+		check, ok := fieldChecks[tag]
+		if !ok {
+			return
+		}
+		for _, p := range check(content) {
+			result = append(result, FieldError{Tag: tag, Line: start + p.index, Message: p.message})
+		}
+	}
+	text = strings.TrimRight(strings.ReplaceAll(text, parser.CRLF, "\n"), "\n")
+	for i, line := range strings.Split(text, "\n") {
+		if match := fieldStart.FindStringSubmatch(line); match != nil {
+			flush()
+			tag, start = match[1], firstLine+i
+			content = []string{line[len(match[0]):]}
+			continue
+		}
+		content = append(content, line)
+	}
+	flush()
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// checkMessageFormat checks field format of the parsed message fields in their lines of the input,
+// or of the serialized message when it was parsed without source.
+func checkMessageFormat(source parser.Source, serialize func() string) error {
+	if len(source.Tokens) == 0 {
+		if err := CheckFormat(serialize(), 1); err != nil {
+			return err
+		}
+		return nil
+	}
+	result := FormatError{}
+	for _, field := range source.Fields() {
+		result = append(result, CheckFormat(field.Raw(), field.Pos.Line)...)
+	}
+	if len(result) > 0 {
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package grammar_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestCheckFormat(t *testing.T) {
+	text := ":20:REFERENCE-TOO-LONG\r\n:25:PL61109010140000071219812874\r\n:28C:123456/1\r\n:60F:C240603EUR1000,00\r\n" +
+		":61:2406030603D1234567890123,45NTRFREF-LONGER-THAN-16//INST\r\n" + strings.Repeat("D", 35) + "\r\n" +
+		":86:1\r\n2\r\n3\r\n4\r\n5\r\n6\r\n7\r\n:62F:C240603EUR1000,00\r\n"
+	assert.Equal(t, grammar.FormatError{
+		{Tag: "20", Line: 1, Message: "length 18 exceeds 16 characters"},
+		{Tag: "28C", Line: 3, Message: "content does not match format 5n[/5n]"},
+		{Tag: "61", Line: 5, Message: "amount 1234567890123,45 exceeds 15 digits"},
+		{Tag: "61", Line: 5, Message: "reference length 18 exceeds 16 characters"},
+		{Tag: "61", Line: 6, Message: "supplementary details length 35 exceeds 34 characters"},
+		{Tag: "86", Line: 13, Message: "7 lines exceed maximum of 6"},
+	}, grammar.CheckFormat(text, 1))
+	assert.Zero(t, grammar.CheckFormat(":20:REF\r\n:86:"+strings.Repeat("X", 65)+"\r\n", 1))
+
+	// Format is checked only on request.
+	validator := grammar.NewValidator(grammar.WithFormatChecks())
+	m := statementPart(t, "PL61109010140000071219812874", "29", "", ":60F:C240603EUR1000,00", "", ":62F:C240603EUR1000,00")
+	m.TransactionRefNo = "STATEMENT-20240603"
+	assert.NoError(t, m.Validate())
+
+	// Messages which are not parsed are checked in serialized form.
+	m.Source = parser.Source{}
+	assert.EqualError(t, validator.Validate(m), "field 20 in line 1: length 18 exceeds 16 characters")
+
+	// Lines of parsed messages include FIN header.
+	data, err := os.ReadFile(filepath.Join("..", "parser", "testdata", "mt950", "input", "fin-envelope.sta"))
+	assert.NoError(t, err)
+	_, err = parser.NewByteParser[grammar.MT950Message]().WithValidator(validator).Parse(data, true, nil)
+	assert.EqualError(t, err, "failed to validate parsed result: field 61 in line 11: institution reference length 20 exceeds 16 characters")
+
+	// Lines of parsed messages include non-standard fields.
+	data = []byte(":20:REF\r\n:25:PL61109010140000071219812874\r\n:28C:1\r\n:NS:LOCAL\r\n:60F:C240603EUR1000,00\r\n" +
+		":61:2406030603D1,00NTRFREF-LONGER-THAN-16\r\n:62F:C240603EUR999,00\r\n")
+	_, err = parser.NewByteParser[grammar.MT940Message]().WithValidator(validator).Parse(data, true, nil)
+	assert.EqualError(t, err, "failed to validate parsed result: field 61 in line 6: reference length 18 exceeds 16 characters")
+}
@@ -1,15 +1,17 @@
 package grammar
 
 import (
+	"encoding/json"
 	"fmt"
 
-	"slices"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/oswida/mt9x/bundle"
 	"github.com/oswida/mt9x/parser"
+	"github.com/shopspring/decimal"
 )
 
 type StatementSection struct {
@@ -18,42 +20,274 @@ type StatementSection struct {
 	// Contains additional information about the transaction detailed in the preceding statement line
 	// and which is to be passed on to the account owner.
 	AccountOwnerInfo []string `parser:"(T86 @CharXSeq ((CRLF @CharXSeq?)*|EOF))?" json:"tag86,omitempty"`
+	// Categories assigned to the transaction by categorisation rules, not a part of the message.
+	Categories []Category `parser:"" json:"categories,omitempty"`
+	// Original text and position of the section.
+	parser.Source
+}
+
+// Category assigned to the transaction with the name of the rule which assigned it.
+type Category struct {
+	Name string `json:"name"`
+	Rule string `json:"rule,omitempty"`
 }
 
 type AccountIdent struct {
 	Account   string  `parser:"@CharXSeq" json:"account"`
 	IdentCode *string `parser:"(CRLF @CharXSeq)?" json:"ident_code,omitempty"` //TODO: validate ident code 4!a2!a2!c[3!c]
+	// Original text and position of the account identification (without the tag).
+	parser.Source
+}
+
+// MTLines formats the account identification as MT message lines, option P is used
+// when the identifier code is present.
+func (a AccountIdent) MTLines() []string {
+	if a.IdentCode == nil {
+		return []string{":25:" + a.Account}
+	}
+	return []string{":25P:" + a.Account, *a.IdentCode}
 }
 
 type StatementNumber struct {
 	StatementNo string  `parser:"@NumSeq" json:"stmt_number"`
 	SequenceNo  *string `parser:"(Slash @NumSeq)?" json:"seq_number,omitempty"`
+	// Original text and position of the statement number (without the tag).
+	parser.Source
+}
+
+// DCMark is a debit/credit mark of the balance or statement line.
+type DCMark string
+
+const (
+	Debit          DCMark = "D"
+	Credit         DCMark = "C"
+	ReversalDebit  DCMark = "RD"
+	ReversalCredit DCMark = "RC"
+)
+
+// IsReversal checks if the mark is a reversal of debit or credit entry.
+func (m DCMark) IsReversal() bool {
+	return m == ReversalDebit || m == ReversalCredit
+}
+
+// DecreasesBalance checks if the mark decreases the balance, i.e. it is a debit or a reversal of credit.
+// It gives the sign of the amount, RC is a reversal of a credit entry, not a debit one.
+func (m DCMark) DecreasesBalance() bool {
+	return m == Debit || m == ReversalCredit
+}
+
+// JSONSchema describes JSON representation of the mark.
+func (DCMark) JSONSchema() map[string]any {
+	return map[string]any{"type": "string", "enum": []string{"D", "C", "RD", "RC"}}
 }
 
 type Balance struct {
-	DCMark   string              `parser:"@DCMark" json:"dc_mark"`
+	DCMark   DCMark              `parser:"@DCMark" json:"dc_mark"`
 	Date     parser.SixDigitDate `parser:"@Date" json:"date"`
 	Currency string              `parser:"@Currency" json:"currency"`
 	Amount   parser.CommaDecimal `parser:"@Amount" json:"amount"`
+	// Original text and position of the balance (without the tag).
+	parser.Source
+}
+
+type EntriesSummary struct {
+	Count    parser.Count        `parser:"@EntryCount" json:"count"`
+	Currency string              `parser:"@Currency" json:"currency"`
+	Amount   parser.CommaDecimal `parser:"@Amount" json:"amount"`
+}
+
+// ValueDateCurrencyAmount is a value date, currency code and amount composite (field 32A).
+type ValueDateCurrencyAmount struct {
+	Date     parser.SixDigitDate `parser:"@Date" json:"value_date"`
+	Currency string              `parser:"@Currency" json:"currency"`
+	Amount   parser.CommaDecimal `parser:"@Amount" json:"amount"`
+}
+
+// Party is a party field (50a, 52a, 56a). Option A contains identifier code (BIC),
+// other options contain name and address lines, which are kept as they are.
+type Party struct {
+	Option          parser.PartyOption `parser:"@(T50A|T50|T52A|T52|T56A|T56)" json:"option"`
+	PartyIdentifier *string            `parser:"(@PartyId CRLF)?" json:"party_identifier,omitempty"`
+	IdentifierCode  *string            `parser:"(@BIC" json:"identifier_code,omitempty"`
+	NameAndAddress  []string           `parser:"| @CharXSeq (CRLF @CharXSeq)*)" json:"name_address,omitempty"`
 }
 
 type Statement struct {
 	ValueDate            parser.SixDigitDate   `parser:"@Date" json:"value_date"`
 	EntryDate            *parser.FourDigitDate `parser:"@Date?" json:"entry_date,omitempty"`
-	DCMark               string                `parser:"@RDCMark" json:"dc_mark"`
+	DCMark               DCMark                `parser:"@RDCMark" json:"dc_mark"`
 	FundsCode            *string               `parser:"@BigLetter?" json:"funds_code,omitempty"`
 	Amount               parser.CommaDecimal   `parser:"@Amount" json:"amount"`
 	TransactionIdent     string                `parser:"@TransIdent" json:"trx_ident"`
 	Reference            string                `parser:"@CharXSeqSlashRestrict" json:"owner_ref"`
 	InstitutionReference *string               `parser:"(TwoSlashes @CharXSeqSlashRestrict?)?" json:"institution_ref,omitempty"`
 	Details              *string               `parser:"(CRLF @CharXSeq)?" json:"details,omitempty"`
+	// Original text and position of the statement line (without the tag).
+	parser.Source
+}
+
+// SignedAmount returns balance amount, negative for debit balances.
+func (b Balance) SignedAmount() decimal.Decimal {
+	if b.DCMark.DecreasesBalance() {
+		return b.Amount.Neg()
+	}
+	return b.Amount.Decimal
+}
+
+// MarshalJSON serializes balance with its signed amount.
+func (b Balance) MarshalJSON() ([]byte, error) {
+	type balance Balance
+	return json.Marshal(struct {
+		balance
+		SignedAmount parser.CommaDecimal `json:"signed_amount"`
+	}{balance(b), parser.CommaDecimal{Decimal: b.SignedAmount()}})
+}
+
+// JSONComputedProperties describes properties added to JSON representation by MarshalJSON.
+func (Balance) JSONComputedProperties() map[string]map[string]any {
+	return map[string]map[string]any{"signed_amount": parser.CommaDecimal{}.JSONSchema()}
+}
+
+// IsReversal checks if the statement line is a reversal of debit or credit entry.
+func (s Statement) IsReversal() bool {
+	return s.DCMark.IsReversal()
+}
+
+// SignedAmount returns statement line amount with sign of the balance change,
+// it is negative for debits and reversals of credits.
+func (s Statement) SignedAmount() decimal.Decimal {
+	if s.DCMark.DecreasesBalance() {
+		return s.Amount.Neg()
+	}
+	return s.Amount.Decimal
+}
+
+// TransactionType returns description and category of the transaction type identification code,
+// it reports false for codes not present in SWIFT or bank-specific code tables of the default provider
+// (see bundle.SetDefaultOptions and bundle.WithStatementIdentCodes).
+func (s Statement) TransactionType() (bundle.CodeInfo, bool) {
+	sicp, err := bundle.DefaultStatementIdentCodeProvider()
+	if err != nil {
+		return bundle.CodeInfo{}, false
+	}
+	return sicp.Lookup(s.TransactionIdent)
+}
+
+// SWIFTTransactionType works as TransactionType, but only SWIFT codes are described. It is used for JSON
+// and CSV output, which does not depend on bank-specific code tables.
+func (s Statement) SWIFTTransactionType() (bundle.CodeInfo, bool) {
+	sicp, err := bundle.DefaultStatementIdentCodeProvider()
+	if err != nil {
+		return bundle.CodeInfo{}, false
+	}
+	return sicp.LookupSWIFT(s.TransactionIdent)
+}
+
+// MarshalJSON serializes statement line with its signed amount and SWIFT transaction type description.
+func (s Statement) MarshalJSON() ([]byte, error) {
+	type statement Statement
+	info, _ := s.SWIFTTransactionType()
+	return json.Marshal(struct {
+		statement
+		SignedAmount           parser.CommaDecimal `json:"signed_amount"`
+		TransactionDescription string              `json:"trx_description,omitempty"`
+		TransactionCategory    string              `json:"trx_category,omitempty"`
+	}{statement(s), parser.CommaDecimal{Decimal: s.SignedAmount()}, info.Description, info.Category})
+}
+
+// JSONComputedProperties describes properties added to JSON representation by MarshalJSON.
+func (Statement) JSONComputedProperties() map[string]map[string]any {
+	return map[string]map[string]any{
+		"signed_amount": parser.CommaDecimal{}.JSONSchema(),
+	}
+}
+
+// JSONOptionalComputedProperties describes properties added to JSON representation by MarshalJSON
+// for known transaction type codes.
+func (Statement) JSONOptionalComputedProperties() map[string]map[string]any {
+	return map[string]map[string]any{
+		"trx_description": {"type": "string"},
+		"trx_category":    {"type": "string"},
+	}
+}
+
+// BookingDate returns entry date with the year taken from the value date,
+// the year is moved when the dates are on the other sides of the year end.
+// If entry date is not present, value date is returned.
+func (s Statement) BookingDate() time.Time {
+	if s.EntryDate == nil {
+		return s.ValueDate.Time
+	}
+	result := time.Date(s.ValueDate.Year(), s.EntryDate.Month(), s.EntryDate.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case result.Sub(s.ValueDate.Time) > 180*24*time.Hour:
+		result = result.AddDate(-1, 0, 0)
+	case s.ValueDate.Sub(result) > 180*24*time.Hour:
+		result = result.AddDate(1, 0, 0)
+	}
+	return result
+}
+
+// --- SERIALIZATION ---
+
+// MTString formats balance field content in MT format.
+func (b Balance) MTString() string {
+	return string(b.DCMark) + b.Date.MTString() + b.Currency + b.Amount.MTString()
+}
+
+// MTString formats value date, currency and amount field content in MT format.
+func (v ValueDateCurrencyAmount) MTString() string {
+	return v.Date.MTString() + v.Currency + v.Amount.MTString()
+}
+
+// MTString formats party field content (without option letter) in MT format.
+func (p Party) MTString() string {
+	lines := []string{}
+	if p.PartyIdentifier != nil {
+		lines = append(lines, *p.PartyIdentifier)
+	}
+	if p.IdentifierCode != nil {
+		lines = append(lines, *p.IdentifierCode)
+	}
+	lines = append(lines, p.NameAndAddress...)
+	return strings.Join(lines, parser.CRLF)
+}
+
+// MTString formats entries summary field content in MT format.
+func (es EntriesSummary) MTString() string {
+	return es.Count.MTString() + es.Currency + es.Amount.MTString()
+}
+
+// MTString formats statement line content in MT format, supplementary details are placed in the second line.
+func (s Statement) MTString() string {
+	result := s.ValueDate.MTString()
+	if s.EntryDate != nil {
+		result += s.EntryDate.MTString()
+	}
+	result += string(s.DCMark) + orEmptyString(s.FundsCode) + s.Amount.MTString() + s.TransactionIdent + s.Reference
+	if s.InstitutionReference != nil {
+		result += "//" + *s.InstitutionReference
+	}
+	if s.Details != nil {
+		result += parser.CRLF + *s.Details
+	}
+	return result
+}
+
+// MTLines formats statement section as MT message lines.
+func (ss StatementSection) MTLines() []string {
+	lines := []string{":61:" + ss.Statement.MTString()}
+	if len(ss.AccountOwnerInfo) > 0 {
+		lines = append(lines, ":86:"+strings.Join(ss.AccountOwnerInfo, parser.CRLF))
+	}
+	return lines
 }
 
 // --- VALIDATIONS ---
 
 // Validate validates balance field according "Network Validated Rules"
-func (b *Balance) Validate(cp *bundle.CurrencyProvider) error {
-	if !slices.Contains(cp.List(), b.Currency) {
+func (b *Balance) Validate(cp CurrencyProvider) error {
+	if !cp.IsValid(b.Currency) {
 		return fmt.Errorf("bad currency code: %s", b.Currency)
 	}
 	// Amount is verified by a lexer
@@ -61,6 +295,54 @@ func (b *Balance) Validate(cp *bundle.CurrencyProvider) error {
 	return nil
 }
 
+// Validate validates entries summary field according "Network Validated Rules"
+func (es *EntriesSummary) Validate(cp CurrencyProvider) error {
+	if !cp.IsValid(es.Currency) {
+		return fmt.Errorf("bad currency code: %s", es.Currency)
+	}
+
+	return nil
+}
+
+// Validate validates value date, currency and amount field according "Network Validated Rules"
+func (v *ValueDateCurrencyAmount) Validate(cp CurrencyProvider) error {
+	if !cp.IsValid(v.Currency) {
+		return fmt.Errorf("bad currency code: %s", v.Currency)
+	}
+
+	return nil
+}
+
+// Validate validates party field, options contains letters of the allowed field options.
+func (p *Party) Validate(options string) error {
+	if len(p.Option) != 1 || !strings.Contains(options, string(p.Option)) {
+		return fmt.Errorf("bad party field option: %s", p.Option)
+	}
+	if p.Option == "A" && p.IdentifierCode == nil {
+		return fmt.Errorf("missing identifier code in party field option A")
+	}
+	if p.Option != "A" && (len(p.NameAndAddress) == 0 || len(p.NameAndAddress) > 4) {
+		return fmt.Errorf("bad number of name and address lines in party field option %s: %d", p.Option, len(p.NameAndAddress))
+	}
+
+	return nil
+}
+
+// checkCurrencies checks if the first two characters of all currency codes are the same,
+// it is the rule C1 of the statement messages.
+func checkCurrencies(currencies ...string) error {
+	prefix := func(c string) string {
+		return c[:min(2, len(c))]
+	}
+	for _, c := range currencies[min(1, len(currencies)):] {
+		if prefix(c) != prefix(currencies[0]) {
+			return fmt.Errorf("currency %s differs from currency %s (C1)", c, currencies[0])
+		}
+	}
+
+	return nil
+}
+
 // trimFirstRune removes first rune from the string and returns the result.
 func trimFirstRune(s string) string {
 	_, i := utf8.DecodeRuneInString(s)
@@ -68,7 +350,7 @@ func trimFirstRune(s string) string {
 }
 
 // isCorrectTransactionIdent checks if transaction identification data is proper according the standard.
-func isCorrectTransactionIdent(ti string, sicp *bundle.StatementIdentCodeProvider) bool {
+func isCorrectTransactionIdent(ti string, sicp StatementIdentCodeProvider) bool {
 	if !strings.HasPrefix(ti, "S") && !strings.HasPrefix(ti, "N") && !strings.HasPrefix(ti, "F") {
 		return false
 	}
@@ -85,12 +367,12 @@ func isCorrectTransactionIdent(ti string, sicp *bundle.StatementIdentCodeProvide
 }
 
 // Validate validates statement section.
-func (ss *StatementSection) Validate(sicp *bundle.StatementIdentCodeProvider) error {
+func (ss *StatementSection) Validate(sicp StatementIdentCodeProvider) error {
 	return ss.Statement.Validate(sicp)
 }
 
 // Validate validates single statement line according "Network Validated Rules".
-func (s *Statement) Validate(sicp *bundle.StatementIdentCodeProvider) error {
+func (s *Statement) Validate(sicp StatementIdentCodeProvider) error {
 	if !isCorrectTransactionIdent(s.TransactionIdent, sicp) {
 		return fmt.Errorf("bad transaction ident: %s", s.TransactionIdent)
 	}
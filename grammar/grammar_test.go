@@ -0,0 +1,142 @@
+package grammar_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/bundle"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+	"github.com/shopspring/decimal"
+)
+
+type serializable interface {
+	parser.MT9xMessage
+	Serialize() string
+}
+
+func assertSerializeRoundTrip[T serializable](t *testing.T, messageType string) {
+	t.Helper()
+	fp := parser.NewFileParser[T]()
+	bp := parser.NewByteParser[T]()
+	basePath := filepath.Join("..", "parser", "testdata", messageType, "input")
+	files, err := os.ReadDir(basePath)
+	assert.NoError(t, err)
+	for _, f := range files {
+		m, err := fp.Parse(filepath.Join(basePath, f.Name()), false, nil)
+		assert.NoError(t, err)
+		result, err := bp.Parse([]byte((*m).Serialize()), false, nil)
+		assert.NoError(t, err, f.Name())
+		// Source text and positions of the fields differ, all other fields are a part of JSON representation.
+		expected, err := json.Marshal(m)
+		assert.NoError(t, err)
+		actual, err := json.Marshal(result)
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual), f.Name())
+	}
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	assertSerializeRoundTrip[grammar.MT940Message](t, "mt940")
+	assertSerializeRoundTrip[grammar.MT950Message](t, "mt950")
+	assertSerializeRoundTrip[grammar.MT941Message](t, "mt941")
+	assertSerializeRoundTrip[grammar.MT942Message](t, "mt942")
+	assertSerializeRoundTrip[grammar.MT900Message](t, "mt900")
+	assertSerializeRoundTrip[grammar.MT910Message](t, "mt910")
+	assertSerializeRoundTrip[grammar.MT920Message](t, "mt920")
+	assertSerializeRoundTrip[grammar.MT970Message](t, "mt970")
+	assertSerializeRoundTrip[grammar.MT971Message](t, "mt971")
+	assertSerializeRoundTrip[grammar.MT972Message](t, "mt972")
+	assertSerializeRoundTrip[grammar.MT973Message](t, "mt973")
+}
+
+func statementPart(t *testing.T, account, number, seq, opening, lines, closing string) grammar.MT940Message {
+	t.Helper()
+	if seq != "" {
+		number += "/" + seq
+	}
+	m, err := parser.NewByteParser[grammar.MT940Message]().Parse([]byte(":20:STMT"+seq+"\r\n:25:"+account+"\r\n:28C:"+number+"\r\n"+
+		opening+"\r\n"+lines+closing+"\r\n"), false, nil)
+	assert.NoError(t, err)
+	return *m
+}
+
+func TestSignedAmounts(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT950Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt950", "input", "nostro.sta"), false, nil)
+	assert.NoError(t, err)
+	signed := []string{}
+	for _, line := range m.Statements {
+		signed = append(signed, string(line.Statement.DCMark)+"="+line.Statement.SignedAmount().String())
+		assert.Equal(t, line.Statement.DCMark == grammar.ReversalCredit, line.Statement.IsReversal())
+	}
+	assert.Equal(t, []string{"C=500000", "RC=-2500", "D=-75.25"}, signed)
+	assert.Equal(t, "-1250000", m.OpeningBalance.SignedAmount().String())
+	assert.Equal(t, "-150000", grammar.Balance{DCMark: grammar.Debit, Amount: parser.CommaDecimal{Decimal: decimal.RequireFromString("150000")}}.SignedAmount().String())
+	assert.True(t, grammar.ReversalCredit.DecreasesBalance())
+	assert.False(t, grammar.ReversalDebit.DecreasesBalance())
+
+	// Signed amounts are the last CSV columns.
+	rows := m.ToCSV(true)
+	assert.Contains(t, rows[0], ",MsgAccOwnerInfo,OB_SignedAmount,SignedAmount,CB_SignedAmount,CAB_SignedAmount,FAB_SignedAmount")
+	header := strings.Split(rows[0], ",")
+	columns := strings.Split(rows[2], ",")
+	assert.Equal(t, len(header), len(columns))
+	start := slices.Index(header, "OB_SignedAmount")
+	assert.Equal(t, []string{"-1250000.00", "-2500.00"}, columns[start:start+2])
+}
+
+func TestTransactionType(t *testing.T) {
+	info, ok := grammar.Statement{TransactionIdent: "NTRF"}.TransactionType()
+	assert.True(t, ok)
+	assert.Equal(t, bundle.CodeInfo{Code: "TRF", Description: "Transfer", Category: "Transfer", Table: bundle.SWIFTCodeTable}, info)
+	info, ok = grammar.Statement{TransactionIdent: "S103"}.TransactionType()
+	assert.True(t, ok)
+	assert.Equal(t, "SWIFT MT103", info.Description)
+	_, ok = grammar.Statement{TransactionIdent: "N911"}.TransactionType()
+	assert.False(t, ok)
+
+	codes, err := bundle.ReadStatementIdentCodes("mbank", strings.NewReader("911,Collect transaction,Collections\n723,Card payment\n"))
+	assert.NoError(t, err)
+	bundle.SetDefaultOptions(bundle.WithStatementIdentCodes("mbank", codes))
+	t.Cleanup(func() { bundle.SetDefaultOptions() })
+	info, ok = grammar.Statement{TransactionIdent: "N911"}.TransactionType()
+	assert.True(t, ok)
+	assert.Equal(t, bundle.CodeInfo{Code: "911", Description: "Collect transaction", Category: "Collections", Table: "mbank"}, info)
+	// JSON output describes only SWIFT codes, so it does not depend on registered tables.
+	s := grammar.Statement{TransactionIdent: "N723", DCMark: grammar.Debit, Amount: parser.CommaDecimal{Decimal: decimal.RequireFromString("5")}}
+	data, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), `"trx_description"`)
+	assert.NotContains(t, string(data), `"trx_category"`)
+	_, ok = s.SWIFTTransactionType()
+	assert.False(t, ok)
+	s.TransactionIdent = "NTRF"
+	data, err = json.Marshal(s)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"trx_description":"Transfer","trx_category":"Transfer"`)
+	// Bank-specific codes are proper only for providers created with their table.
+	sicp, err := bundle.NewStatementIdentificationCodeProvider(bundle.WithStatementIdentCodes("mbank", codes))
+	assert.NoError(t, err)
+	assert.True(t, sicp.IsProperCode("723"))
+	sicp, err = bundle.NewStatementIdentificationCodeProvider(bundle.WithFS(fstest.MapFS{}))
+	assert.NoError(t, err)
+	assert.False(t, sicp.IsProperCode("723"))
+}
+
+func TestMT972Errors(t *testing.T) {
+	m, err := parser.NewFileParser[grammar.MT972Message]().Parse(filepath.Join("..", "parser", "testdata", "mt972", "input", "interim.sta"), true, nil)
+	assert.NoError(t, err)
+	m.Statements[0].Statement.TransactionIdent = "NXYZ"
+	err = m.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error parsing MT972 statement: ")
+	err = grammar.MT970Message(*m).Validate()
+	assert.Contains(t, err.Error(), "error parsing MT970 statement: ")
+}
@@ -0,0 +1,186 @@
+package grammar
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// JSON representation of MT9x messages.
+//
+// Every message is serialized as an object with field tags as keys ("tag20", "tag61", ...)
+// and nested objects for composite fields. Amounts are strings with a dot as a decimal sign,
+// dates are RFC 3339 timestamps in UTC. Entry dates (MMDD) do not carry the year, so it is always 0000.
+// Optional fields are omitted when not present in the message.
+// Balances and statement lines have also computed "signed_amount", negative for debits and reversals of credits.
+//
+// The shape is versioned with JSONVersion, the version is changed on every incompatible change.
+// JSONDocument can be used to store the message together with the version and the message type,
+// JSON Schema of the shape is available with JSONSchema and published in the schema directory.
+
+const (
+	JSONVersion   = 1
+	JSONSchemaURI = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// JSONDocument wraps MT9x message with JSON shape version and message type.
+type JSONDocument[T parser.MT9xMessage] struct {
+	Version     int    `json:"version"`
+	MessageType string `json:"message_type"`
+	Message     T      `json:"message"`
+}
+
+// NewJSONDocument creates document for the message in current JSON shape version.
+func NewJSONDocument[T parser.MT9xMessage](m T) JSONDocument[T] {
+	return JSONDocument[T]{
+		Version:     JSONVersion,
+		MessageType: parser.MessageType(m),
+		Message:     m,
+	}
+}
+
+// UnmarshalJSONDocument loads message stored as JSONDocument, checking version and message type.
+func UnmarshalJSONDocument[T parser.MT9xMessage](data []byte) (*T, error) {
+	doc := JSONDocument[T]{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON document: %w", err)
+	}
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported JSON document version: %d", doc.Version)
+	}
+	var m T
+	if expected := parser.MessageType(m); expected != "" && doc.MessageType != expected {
+		return nil, fmt.Errorf("bad message type: %s, expected %s", doc.MessageType, expected)
+	}
+
+	return &doc.Message, nil
+}
+
+// schemaProvider is implemented by types with custom JSON representation.
+type schemaProvider interface {
+	JSONSchema() map[string]any
+}
+
+var schemaProviderType = reflect.TypeFor[schemaProvider]()
+
+// computedPropertiesProvider is implemented by types adding computed properties to JSON representation.
+type computedPropertiesProvider interface {
+	JSONComputedProperties() map[string]map[string]any
+}
+
+var computedPropertiesProviderType = reflect.TypeFor[computedPropertiesProvider]()
+
+// optionalComputedPropertiesProvider is implemented by types adding computed properties, which can be omitted,
+// to JSON representation.
+type optionalComputedPropertiesProvider interface {
+	JSONOptionalComputedProperties() map[string]map[string]any
+}
+
+var optionalComputedPropertiesProviderType = reflect.TypeFor[optionalComputedPropertiesProvider]()
+
+// JSONSchema generates JSON Schema for the JSON representation of the MT9x message.
+func JSONSchema[T parser.MT9xMessage]() map[string]any {
+	var m T
+	defs := map[string]any{}
+	result := objectSchema(reflect.TypeFor[T](), defs)
+	result["$schema"] = JSONSchemaURI
+	result["title"] = "MT" + parser.MessageType(m)
+	result["$comment"] = fmt.Sprintf("JSON shape version %d", JSONVersion)
+	result["$defs"] = defs
+
+	return result
+}
+
+// typeSchema returns schema for the type, struct types are registered in defs and referenced.
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		return structSchema(t, defs)
+	}
+
+	return map[string]any{}
+}
+
+// structSchema registers object schema of the struct type in defs and returns reference to it.
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+	if _, ok := defs[t.Name()]; ok {
+		return ref
+	}
+	defs[t.Name()] = nil // placeholder for recursive types
+	defs[t.Name()] = objectSchema(t, defs)
+
+	return ref
+}
+
+// objectSchema returns schema of the struct type based on its JSON tags.
+func objectSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	addProperties(t, defs, properties, &required)
+	if t.Implements(computedPropertiesProviderType) {
+		computed := reflect.Zero(t).Interface().(computedPropertiesProvider).JSONComputedProperties()
+		for _, name := range slices.Sorted(maps.Keys(computed)) {
+			properties[name] = computed[name]
+			required = append(required, name)
+		}
+	}
+	if t.Implements(optionalComputedPropertiesProviderType) {
+		for name, schema := range reflect.Zero(t).Interface().(optionalComputedPropertiesProvider).JSONOptionalComputedProperties() {
+			properties[name] = schema
+		}
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// addProperties adds properties of the struct fields, fields of embedded structs are added as the struct ones.
+func addProperties(t reflect.Type, defs map[string]any, properties map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			addProperties(f.Type, defs, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = typeSchema(f.Type, defs)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
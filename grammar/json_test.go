@@ -0,0 +1,58 @@
+package grammar_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+	"gotest.tools/v3/golden"
+)
+
+func TestMT940JSONRoundTrip(t *testing.T) {
+	basePath := filepath.Join("..", "parser", "testdata", "mt940", "expected")
+	files, err := os.ReadDir(basePath)
+	assert.NoError(t, err)
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(basePath, f.Name()))
+		assert.NoError(t, err)
+		m := grammar.MT940Message{}
+		assert.NoError(t, json.Unmarshal(data, &m), f.Name())
+		value, err := json.MarshalIndent(m, "", " ")
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), string(value), f.Name())
+
+		doc, err := json.Marshal(grammar.NewJSONDocument(m))
+		assert.NoError(t, err)
+		loaded, err := grammar.UnmarshalJSONDocument[grammar.MT940Message](doc)
+		assert.NoError(t, err)
+		assert.Equal(t, m, *loaded, f.Name())
+	}
+}
+
+func assertSchema[T parser.MT9xMessage](t *testing.T) {
+	t.Helper()
+	var m T
+	value, err := json.MarshalIndent(grammar.JSONSchema[T](), "", " ")
+	assert.NoError(t, err)
+	path, err := filepath.Abs(filepath.Join("..", "schema", "mt"+parser.MessageType(m)+".schema.json"))
+	assert.NoError(t, err)
+	golden.Assert(t, string(value), path)
+}
+
+func TestJSONSchema(t *testing.T) {
+	assertSchema[grammar.MT940Message](t)
+	assertSchema[grammar.MT950Message](t)
+	assertSchema[grammar.MT941Message](t)
+	assertSchema[grammar.MT942Message](t)
+	assertSchema[grammar.MT900Message](t)
+	assertSchema[grammar.MT910Message](t)
+	assertSchema[grammar.MT920Message](t)
+	assertSchema[grammar.MT970Message](t)
+	assertSchema[grammar.MT971Message](t)
+	assertSchema[grammar.MT972Message](t)
+	assertSchema[grammar.MT973Message](t)
+}
@@ -0,0 +1,116 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT900 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// MT900Message represents MT900 (confirmation of debit) standard message structure.
+type MT900Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Contains the reference for the account owner (Receiver), e.g. field 21 of the message which initiated the debit.
+	RelatedReference string `parser:"T21 @CharXSeqSlashRestrict CRLF" json:"tag21"`
+	// Identifies the account which has been debited and optionally the identifier code of the account owner.
+	AccountIdentification AccountIdent `parser:"(T25|T25P) @@ CRLF" json:"tag25"`
+	// Indicates the date, time and time zone at which the account was debited.
+	DateTimeIndication *parser.DateTime `parser:"(T13D @DateTimeInd CRLF)?" json:"tag13d,omitempty"`
+	// Contains the value date, currency code and amount of the debit.
+	ValueDateCurrencyAmount ValueDateCurrencyAmount `parser:"T32A @@ (CRLF|EOF)" json:"tag32a"`
+	// Identifies the financial institution which originated the transaction (option A or D).
+	OrderingInstitution *Party `parser:"((?= T52A|T52) @@ (CRLF|EOF))?" json:"tag52,omitempty"`
+	// Additional information for the Receiver.
+	SenderToReceiverInfo []string `parser:"(T72 @CharXSeq ((CRLF @CharXSeq?)*|EOF))?" json:"tag72,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT900Message) MessageType() string {
+	return "900"
+}
+
+// Validate validates MT900 messages according "Network Validated Rules"
+func (m MT900Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT900Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := validateConfirmation(cp, m.TransactionRefNo, m.RelatedReference, m.ValueDateCurrencyAmount, m.SenderToReceiverInfo); err != nil {
+		return err
+	}
+
+	if m.OrderingInstitution != nil {
+		if err := m.OrderingInstitution.Validate("AD"); err != nil {
+			return fmt.Errorf("bad ordering institution: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT900Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Serialize formats message in MT900 format with CRLF line endings.
+func (m MT900Message) Serialize() string {
+	lines := confirmationLines(m.TransactionRefNo, m.RelatedReference, m.AccountIdentification, m.DateTimeIndication, m.ValueDateCurrencyAmount)
+	lines = appendParty(lines, "52", m.OrderingInstitution)
+	if len(m.SenderToReceiverInfo) > 0 {
+		lines = append(lines, ":72:"+strings.Join(m.SenderToReceiverInfo, parser.CRLF))
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
+
+// validateConfirmation validates fields common for MT900 and MT910 messages.
+func validateConfirmation(cp CurrencyProvider, ref, relatedRef string, amount ValueDateCurrencyAmount, info []string) error {
+	if !isCorrectReference(ref) {
+		return fmt.Errorf("bad transaction reference number: %s", ref)
+	}
+
+	if !isCorrectReference(relatedRef) {
+		return fmt.Errorf("bad related reference number: %s", relatedRef)
+	}
+
+	if err := amount.Validate(cp); err != nil {
+		return fmt.Errorf("bad value date, currency and amount: %w", err)
+	}
+
+	if len(info) > 6 {
+		return fmt.Errorf("too many sender to receiver information lines: %d", len(info))
+	}
+
+	return nil
+}
+
+// confirmationLines formats fields common for MT900 and MT910 messages as MT message lines.
+func confirmationLines(ref, relatedRef string, account AccountIdent, dateTime *parser.DateTime, amount ValueDateCurrencyAmount) []string {
+	lines := append([]string{":20:" + ref, ":21:" + relatedRef}, account.MTLines()...)
+	if dateTime != nil {
+		lines = append(lines, ":13D:"+dateTime.MTString())
+	}
+	return append(lines, ":32A:"+amount.MTString())
+}
+
+// appendParty appends party field with the given tag number, if present.
+func appendParty(lines []string, tag string, p *Party) []string {
+	if p == nil {
+		return lines
+	}
+	return append(lines, ":"+tag+string(p.Option)+":"+p.MTString())
+}
@@ -0,0 +1,101 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT910 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// MT910Message represents MT910 (confirmation of credit) standard message structure.
+type MT910Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Contains the reference for the account owner (Receiver), e.g. field 21 of the message which initiated the credit.
+	RelatedReference string `parser:"T21 @CharXSeqSlashRestrict CRLF" json:"tag21"`
+	// Identifies the account which has been credited and optionally the identifier code of the account owner.
+	AccountIdentification AccountIdent `parser:"(T25|T25P) @@ CRLF" json:"tag25"`
+	// Indicates the date, time and time zone at which the account was credited.
+	DateTimeIndication *parser.DateTime `parser:"(T13D @DateTimeInd CRLF)?" json:"tag13d,omitempty"`
+	// Contains the value date, currency code and amount of the credit.
+	ValueDateCurrencyAmount ValueDateCurrencyAmount `parser:"T32A @@ (CRLF|EOF)" json:"tag32a"`
+	// Identifies the customer which originated the transaction (option A, F or K).
+	OrderingCustomer *Party `parser:"((?= T50A|T50) @@ (CRLF|EOF))?" json:"tag50,omitempty"`
+	// Identifies the financial institution which originated the transaction (option A or D).
+	OrderingInstitution *Party `parser:"((?= T52A|T52) @@ (CRLF|EOF))?" json:"tag52,omitempty"`
+	// Identifies the financial institution from which the Sender received the funds (option A or D).
+	Intermediary *Party `parser:"((?= T56A|T56) @@ (CRLF|EOF))?" json:"tag56,omitempty"`
+	// Additional information for the Receiver.
+	SenderToReceiverInfo []string `parser:"(T72 @CharXSeq ((CRLF @CharXSeq?)*|EOF))?" json:"tag72,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT910Message) MessageType() string {
+	return "910"
+}
+
+// Validate validates MT910 messages according "Network Validated Rules"
+func (m MT910Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT910Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+
+	if err := validateConfirmation(cp, m.TransactionRefNo, m.RelatedReference, m.ValueDateCurrencyAmount, m.SenderToReceiverInfo); err != nil {
+		return err
+	}
+
+	// C1: either field 50a or field 52a must be present.
+	if m.OrderingCustomer == nil && m.OrderingInstitution == nil {
+		return fmt.Errorf("either ordering customer or ordering institution must be present (C1)")
+	}
+
+	if m.OrderingCustomer != nil {
+		if err := m.OrderingCustomer.Validate("AFK"); err != nil {
+			return fmt.Errorf("bad ordering customer: %w", err)
+		}
+	}
+
+	if m.OrderingInstitution != nil {
+		if err := m.OrderingInstitution.Validate("AD"); err != nil {
+			return fmt.Errorf("bad ordering institution: %w", err)
+		}
+	}
+
+	if m.Intermediary != nil {
+		if err := m.Intermediary.Validate("AD"); err != nil {
+			return fmt.Errorf("bad intermediary: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT910Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Serialize formats message in MT910 format with CRLF line endings.
+func (m MT910Message) Serialize() string {
+	lines := confirmationLines(m.TransactionRefNo, m.RelatedReference, m.AccountIdentification, m.DateTimeIndication, m.ValueDateCurrencyAmount)
+	lines = appendParty(lines, "50", m.OrderingCustomer)
+	lines = appendParty(lines, "52", m.OrderingInstitution)
+	lines = appendParty(lines, "56", m.Intermediary)
+	if len(m.SenderToReceiverInfo) > 0 {
+		lines = append(lines, ":72:"+strings.Join(m.SenderToReceiverInfo, parser.CRLF))
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
@@ -0,0 +1,21 @@
+package grammar_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestMT910Validate(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT910Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt910", "input", "spec-example.sta"), true, nil)
+	assert.NoError(t, err)
+	m.OrderingInstitution = nil
+	assert.EqualError(t, m.Validate(), "either ordering customer or ordering institution must be present (C1)")
+	m.OrderingInstitution = m.Intermediary
+	m.OrderingInstitution.Option = "K"
+	assert.EqualError(t, m.Validate(), "bad ordering institution: bad party field option: K")
+}
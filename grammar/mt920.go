@@ -0,0 +1,166 @@
+package grammar
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT920 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// FloorLimit specifies the amount above which entries are reported in MT942 (field 34F).
+type FloorLimit struct {
+	Currency string              `parser:"@Currency" json:"currency"`
+	DCMark   *string             `parser:"@DCMark?" json:"dc_mark,omitempty"`
+	Amount   parser.CommaDecimal `parser:"@Amount" json:"amount"`
+}
+
+// StatementRequest is a single request of MT920 message.
+type StatementRequest struct {
+	// Identifies the message type which is being requested.
+	MessageRequested string `parser:"T12 @MsgType CRLF" json:"tag12"`
+	// Identifies the account for which the information is requested.
+	Account string `parser:"T25 @CharXSeq (CRLF|EOF)" json:"tag25"`
+	// Specifies the floor limits for debit and credit entries, used only for MT942 requests.
+	FloorLimits []FloorLimit `parser:"(T34F @@ (CRLF|EOF))*" json:"tag34f,omitempty"`
+}
+
+// MT920Message represents MT920 (request message) standard message structure.
+type MT920Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Requested statements and reports.
+	Requests []StatementRequest `parser:"@@+" json:"requests"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// requestableTypes contains message types which can be requested with MT920.
+var requestableTypes = []string{"940", "941", "942", "950"}
+
+// MessageType returns SWIFT message type number.
+func (m MT920Message) MessageType() string {
+	return "920"
+}
+
+// Validate validates MT920 messages according "Network Validated Rules"
+func (m MT920Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT920Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	if len(m.Requests) == 0 {
+		return fmt.Errorf("no requests in the message")
+	}
+
+	for i, r := range m.Requests {
+		if err := r.Validate(cp); err != nil {
+			return fmt.Errorf("bad request %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT920Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Validate validates single statement request according "Network Validated Rules"
+func (r *StatementRequest) Validate(cp CurrencyProvider) error {
+	if !slices.Contains(requestableTypes, r.MessageRequested) {
+		return fmt.Errorf("bad requested message type: %s, allowed types: %s", r.MessageRequested, strings.Join(requestableTypes, ", "))
+	}
+
+	if r.Account == "" || len(r.Account) > 35 {
+		return fmt.Errorf("bad account identification: %s", r.Account)
+	}
+
+	// C1: floor limit must be present when MT942 is requested.
+	if r.MessageRequested == "942" && len(r.FloorLimits) == 0 {
+		return fmt.Errorf("floor limit is mandatory for requested message type 942 (C1)")
+	}
+
+	if len(r.FloorLimits) > 2 {
+		return fmt.Errorf("too many floor limits: %d", len(r.FloorLimits))
+	}
+
+	for _, fl := range r.FloorLimits {
+		if !cp.IsValid(fl.Currency) {
+			return fmt.Errorf("bad currency code: %s", fl.Currency)
+		}
+	}
+
+	// C2: debit/credit marks of the floor limits.
+	if err := checkFloorLimitMarks(r.FloorLimits); err != nil {
+		return err
+	}
+
+	if len(r.FloorLimits) == 2 {
+		// C3: currency code must be the same in both floor limits.
+		first, second := r.FloorLimits[0], r.FloorLimits[1]
+		if first.Currency != second.Currency {
+			return fmt.Errorf("floor limit currency %s differs from currency %s (C3)", second.Currency, first.Currency)
+		}
+	}
+
+	return nil
+}
+
+// checkFloorLimitMarks checks debit/credit marks of floor limits (rule C2 of MT920 and MT942).
+// Single floor limit applies to both debit and credit entries, so it has no mark. When both floor limits
+// are present, the first one is for debit and the second one for credit entries.
+func checkFloorLimitMarks(limits []FloorLimit) error {
+	switch len(limits) {
+	case 1:
+		if limits[0].DCMark != nil {
+			return fmt.Errorf("single floor limit must not have debit/credit mark (C2)")
+		}
+	case 2:
+		first, second := limits[0], limits[1]
+		if first.DCMark == nil || *first.DCMark != "D" || second.DCMark == nil || *second.DCMark != "C" {
+			return fmt.Errorf("first floor limit must be debit and the second one credit (C2)")
+		}
+	}
+
+	return nil
+}
+
+// MTString formats floor limit field content in MT format.
+func (fl FloorLimit) MTString() string {
+	return fl.Currency + orEmptyString(fl.DCMark) + fl.Amount.MTString()
+}
+
+// MTLines formats statement request as MT message lines.
+func (r StatementRequest) MTLines() []string {
+	lines := []string{":12:" + r.MessageRequested, ":25:" + r.Account}
+	for _, fl := range r.FloorLimits {
+		lines = append(lines, ":34F:"+fl.MTString())
+	}
+	return lines
+}
+
+// Serialize formats message in MT920 format with CRLF line endings.
+func (m MT920Message) Serialize() string {
+	lines := []string{":20:" + m.TransactionRefNo}
+	for _, r := range m.Requests {
+		lines = append(lines, r.MTLines()...)
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
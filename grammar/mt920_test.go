@@ -0,0 +1,43 @@
+package grammar_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+	"github.com/shopspring/decimal"
+)
+
+func TestMT920Request(t *testing.T) {
+	debit, credit := "D", "C"
+	m := grammar.MT920Message{
+		TransactionRefNo: "REQ1",
+		Requests: []grammar.StatementRequest{
+			{MessageRequested: "950", Account: "123-45678"},
+			{MessageRequested: "942", Account: "123-45678", FloorLimits: []grammar.FloorLimit{
+				{Currency: "EUR", DCMark: &debit, Amount: parser.CommaDecimal{Decimal: decimal.RequireFromString("1000")}},
+				{Currency: "EUR", DCMark: &credit, Amount: parser.CommaDecimal{Decimal: decimal.RequireFromString("500.5")}},
+			}},
+		},
+	}
+	assert.NoError(t, m.Validate())
+	assert.Equal(t, ":20:REQ1\r\n:12:950\r\n:25:123-45678\r\n:12:942\r\n:25:123-45678\r\n"+
+		":34F:EURD1000,\r\n:34F:EURC500,5\r\n", m.Serialize())
+
+	parsed, err := parser.NewByteParser[grammar.MT920Message]().Parse([]byte(m.Serialize()), true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Serialize(), parsed.Serialize())
+
+	m.Requests[0].MessageRequested = "900"
+	assert.EqualError(t, m.Validate(), "bad request 1: bad requested message type: 900, allowed types: 940, 941, 942, 950")
+	m.Requests[0].MessageRequested = "942"
+	assert.EqualError(t, m.Validate(), "bad request 1: floor limit is mandatory for requested message type 942 (C1)")
+	m.Requests[0].MessageRequested = "940"
+	m.Requests[1].FloorLimits[0].Currency = "USD"
+	assert.EqualError(t, m.Validate(), "bad request 2: floor limit currency EUR differs from currency USD (C3)")
+	m.Requests[1].FloorLimits = m.Requests[1].FloorLimits[1:]
+	assert.EqualError(t, m.Validate(), "bad request 2: single floor limit must not have debit/credit mark (C2)")
+	m.Requests[1].FloorLimits[0].DCMark = nil
+	assert.NoError(t, m.Validate())
+}
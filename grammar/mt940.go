@@ -5,11 +5,11 @@ import (
 	"strings"
 	"time"
 
-	"github.com/oswida/mt9x/bundle"
+	"github.com/oswida/mt9x/parser"
 )
 
 const (
-	CSVHeader = `TransactionRefNo,RelatedReference,Account,IdentCode,StmtNo,SeqNo,OB_DC,OB_Date,OB_Curr,OB_Amount,ValueDate,EntryDate,DC,FCode,Amount,TrxIdent,Reference,InstitutionRef,Details,AccOwnerInfo,CB_DC,CB_Date,CB_Curr,CB_Amount,CAB_DC,CAB_Date,CAB_Curr,CAB_Amount,FAB_DC,FAB_Date,FAB_Curr,FAB_Amount,MsgAccOwnerInfo`
+	CSVHeader = `TransactionRefNo,RelatedReference,Account,IdentCode,StmtNo,SeqNo,OB_DC,OB_Date,OB_Curr,OB_Amount,ValueDate,EntryDate,DC,FCode,Amount,TrxIdent,Reference,InstitutionRef,Details,AccOwnerInfo,CB_DC,CB_Date,CB_Curr,CB_Amount,CAB_DC,CAB_Date,CAB_Curr,CAB_Amount,FAB_DC,FAB_Date,FAB_Curr,FAB_Amount,MsgAccOwnerInfo,OB_SignedAmount,SignedAmount,CB_SignedAmount,CAB_SignedAmount,FAB_SignedAmount,BookingDate,TrxDescription,TrxCategory`
 )
 
 // Grammar for MT940 file, according standard available here:
@@ -27,13 +27,17 @@ type MT940Message struct {
 	// Contains the sequential number of the statement, optionally followed by the sequence number of the message
 	// within that statement when more than one message is sent for one statement.
 	StatementNumber StatementNumber `parser:"T28C @@ CRLF" json:"tag28"`
+	// Set when opening balance is intermediate (:60M:), i.e. message is not the first one of the statement.
+	IntermediateOpening bool `parser:"(T60F|@T60M)" json:"intermediate_opening,omitempty"`
 	// Specifies, for the (intermediate - M) opening balance, whether it is a debit or credit balance,
 	// the date, the currency and the amount of the balance.
-	OpeningBalance Balance `parser:"(T60F|T60M) @@ (CRLF|EOF)" json:"tag60"`
+	OpeningBalance Balance `parser:"@@ (CRLF|EOF)" json:"tag60"`
 	// Statement information
 	Statements []StatementSection `parser:"@@*" json:"statements,omitempty"`
+	// Set when closing balance is intermediate (:62M:), i.e. statement continues in the next message.
+	IntermediateClosing bool `parser:"(T62F|@T62M)" json:"intermediate_closing,omitempty"`
 	// Specifies, for the (intermediate) closing balance.
-	ClosingBalance Balance `parser:"(T62F|T62M) @@ (CRLF|EOF)" json:"tag62"`
+	ClosingBalance Balance `parser:"@@ (CRLF|EOF)" json:"tag62"`
 	// Indicates the funds which are available to the account owner (if credit balance)
 	// or the balance which is subject to interest charges (if debit balance).
 	ClosingAvailableBalance *Balance `parser:"(T64 @@ (CRLF|EOF))?" json:"tag64,omitempty"`
@@ -42,17 +46,31 @@ type MT940Message struct {
 	ForwardAvailableBalance []Balance `parser:"(T65 @@ (CRLF|EOF))*" json:"tag65,omitempty"`
 	// Summarizing owner info
 	AccountOwnerInfo []string `parser:"(T86 @CharXSeq ((CRLF @CharXSeq?)*|EOF))?" json:"tag86,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT940Message) MessageType() string {
+	return "940"
 }
 
 // Validate validates MT940 messages according "Network Validated Rules"
 func (m MT940Message) Validate() error {
-	cp, err := bundle.NewCurrencyProvider()
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT940Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
 	if err != nil {
-		return fmt.Errorf("cannot create currency provider: %v", err)
+		return err
 	}
-	sicp, err := bundle.NewStatementIdentificationCodeProvider()
+	sicp, err := v.statementIdentCodeProvider()
 	if err != nil {
-		return fmt.Errorf("cannot create statement identification provider: %v", err)
+		return err
 	}
 
 	if !isCorrectReference(m.TransactionRefNo) {
@@ -89,6 +107,11 @@ func (m MT940Message) Validate() error {
 	return nil
 }
 
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT940Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
 // isCorrectReference checks if reference number is proper according the standard.
 func isCorrectReference(ref string) bool {
 	return !strings.HasPrefix(ref, "/") &&
@@ -103,72 +126,106 @@ func orEmptyString(data *string) string {
 	return ""
 }
 
+// Serialize formats message in MT940 format with CRLF line endings.
+func (m MT940Message) Serialize() string {
+	lines := []string{":20:" + m.TransactionRefNo}
+	if m.RelatedReference != nil {
+		lines = append(lines, ":21:"+*m.RelatedReference)
+	}
+	lines = append(lines, m.AccountIdentification.MTLines()...)
+	stmtNo := m.StatementNumber.StatementNo
+	if m.StatementNumber.SequenceNo != nil {
+		stmtNo += "/" + *m.StatementNumber.SequenceNo
+	}
+	lines = append(lines, ":28C:"+stmtNo)
+	if m.IntermediateOpening {
+		lines = append(lines, ":60M:"+m.OpeningBalance.MTString())
+	} else {
+		lines = append(lines, ":60F:"+m.OpeningBalance.MTString())
+	}
+	for _, stmt := range m.Statements {
+		lines = append(lines, stmt.MTLines()...)
+	}
+	if m.IntermediateClosing {
+		lines = append(lines, ":62M:"+m.ClosingBalance.MTString())
+	} else {
+		lines = append(lines, ":62F:"+m.ClosingBalance.MTString())
+	}
+	if m.ClosingAvailableBalance != nil {
+		lines = append(lines, ":64:"+m.ClosingAvailableBalance.MTString())
+	}
+	for _, fab := range m.ForwardAvailableBalance {
+		lines = append(lines, ":65:"+fab.MTString())
+	}
+	if len(m.AccountOwnerInfo) > 0 {
+		lines = append(lines, ":86:"+strings.Join(m.AccountOwnerInfo, parser.CRLF))
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
+
+// csvColumns returns balance columns: mark, date, currency and amount.
+func (b Balance) csvColumns() []string {
+	return []string{string(b.DCMark), b.Date.Format(time.DateOnly), b.Currency, b.Amount.StringFixed(2)}
+}
+
 // ToCSV serializes message to CSV row set.
 // Statements are base for row set, rest of envelope data is duplicated in every row.
 // Additional header row is added at the beginning.
 func (m MT940Message) ToCSV(serializeT65 bool) []string {
 	rows := []string{CSVHeader}
-	for _, stmt := range m.Statements {
+	for _, t := range m.Transactions() {
 		row := []string{}
-		row = append(row, m.TransactionRefNo)
+		row = append(row, t.TransactionRefNo)
 		row = append(row, orEmptyString(m.RelatedReference))
-		row = append(row, m.AccountIdentification.Account)
+		row = append(row, t.Account)
 		row = append(row, orEmptyString(m.AccountIdentification.IdentCode))
-		row = append(row, m.StatementNumber.StatementNo)
-		row = append(row, orEmptyString(m.StatementNumber.SequenceNo))
-		row = append(row,
-			m.OpeningBalance.DCMark,
-			m.OpeningBalance.Date.Format(time.DateOnly),
-			m.OpeningBalance.Currency,
-			m.OpeningBalance.Amount.StringFixed(2))
-		row = append(row, stmt.Statement.ValueDate.Format(time.DateOnly))
+		row = append(row, t.StatementNo)
+		row = append(row, t.SequenceNo)
+		row = append(row, m.OpeningBalance.csvColumns()...)
+		row = append(row, t.ValueDate.Format(time.DateOnly))
+		// Entry date is given without year, as it is in the statement line.
 		edate := ""
-		if stmt.Statement.EntryDate != nil {
-			edate = stmt.Statement.EntryDate.Format(time.DateOnly)
+		if entryDate := m.Statements[t.Index].Statement.EntryDate; entryDate != nil {
+			edate = entryDate.Format(time.DateOnly)
 		}
 		row = append(row, edate)
-		row = append(row, stmt.Statement.DCMark)
-		row = append(row, orEmptyString(stmt.Statement.FundsCode))
-		row = append(row, stmt.Statement.Amount.StringFixed(2))
-		row = append(row, stmt.Statement.TransactionIdent)
-		row = append(row, stmt.Statement.Reference)
-		row = append(row, orEmptyString(stmt.Statement.InstitutionReference))
-		row = append(row, orEmptyString(stmt.Statement.Details))
-		row = append(row, strings.Join(stmt.AccountOwnerInfo, " "))
-		row = append(row,
-			m.ClosingBalance.DCMark,
-			m.ClosingBalance.Date.Format(time.DateOnly),
-			m.ClosingBalance.Currency,
-			m.ClosingBalance.Amount.StringFixed(2))
+		row = append(row, string(t.DCMark))
+		row = append(row, t.FundsCode)
+		row = append(row, t.Amount.StringFixed(2))
+		row = append(row, t.TransactionIdent)
+		row = append(row, t.Reference)
+		row = append(row, t.InstitutionReference)
+		row = append(row, t.Details)
+		row = append(row, strings.Join(t.AccountOwnerInfo, " "))
+		row = append(row, m.ClosingBalance.csvColumns()...)
+		cabSigned := ""
 		if m.ClosingAvailableBalance != nil {
-			row = append(row,
-				m.ClosingAvailableBalance.DCMark,
-				m.ClosingAvailableBalance.Date.Format(time.DateOnly),
-				m.ClosingAvailableBalance.Currency,
-				m.ClosingAvailableBalance.Amount.StringFixed(2))
+			row = append(row, m.ClosingAvailableBalance.csvColumns()...)
+			cabSigned = m.ClosingAvailableBalance.SignedAmount().StringFixed(2)
 		} else {
 			row = append(row, "", "", "", "")
 		}
+		fabSigned := []string{}
 		if serializeT65 {
-			dc := []string{}
-			dt := []string{}
-			cur := []string{}
-			amt := []string{}
+			columns := make([][]string, 4)
 			for _, fab := range m.ForwardAvailableBalance {
-				dc = append(dc, fab.DCMark)
-				dt = append(dt, fab.Date.Format(time.DateOnly))
-				cur = append(cur, fab.Currency)
-				amt = append(amt, fab.Amount.StringFixed(2))
+				for i, c := range fab.csvColumns() {
+					columns[i] = append(columns[i], c)
+				}
+				fabSigned = append(fabSigned, fab.SignedAmount().StringFixed(2))
+			}
+			for _, c := range columns {
+				row = append(row, strings.Join(c, "/"))
 			}
-			row = append(row,
-				strings.Join(dc, "/"),
-				strings.Join(dt, "/"),
-				strings.Join(cur, "/"),
-				strings.Join(amt, "/"))
 		} else {
 			row = append(row, "", "", "", "")
 		}
 		row = append(row, strings.Join(m.AccountOwnerInfo, " "))
+		// Signed amounts, booking date and transaction type are added after the original columns.
+		row = append(row, m.OpeningBalance.SignedAmount().StringFixed(2), t.SignedAmount.StringFixed(2),
+			m.ClosingBalance.SignedAmount().StringFixed(2), cabSigned, strings.Join(fabSigned, "/"))
+		row = append(row, t.BookingDate.Format(time.DateOnly))
+		row = append(row, t.TransactionDescription, t.TransactionCategory)
 		rows = append(rows, strings.Join(row, ","))
 	}
 	return rows
@@ -0,0 +1,146 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT941 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// MT941Message represents MT941 (balance report) standard message structure.
+type MT941Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// If the MT 941 is sent in response to an MT 920 Request Message, this field must contain the field 20 Transaction Reference Number of the request message.
+	RelatedReference *string `parser:"(T21 @CharXSeqSlashRestrict CRLF)?" json:"tag21,omitempty"`
+	// Identifies the account and optionally the identifier code of the account owner for which the report is sent.
+	AccountIdentification AccountIdent `parser:"(T25|T25P) @@ CRLF" json:"tag25"`
+	// Contains the sequential number of the report, optionally followed by the sequence number of the message
+	// within that report.
+	StatementNumber StatementNumber `parser:"T28 @@ CRLF" json:"tag28"`
+	// Indicates the date, time and time zone at which the report was created.
+	DateTimeIndication *parser.DateTime `parser:"(T13D @DateTimeInd CRLF)?" json:"tag13d,omitempty"`
+	// Specifies the opening balance of the report.
+	OpeningBalance *Balance `parser:"(T60F @@ (CRLF|EOF))?" json:"tag60,omitempty"`
+	// Indicates the total number and amount of debit entries since the last report.
+	DebitEntries *EntriesSummary `parser:"(T90D @@ (CRLF|EOF))?" json:"tag90d,omitempty"`
+	// Indicates the total number and amount of credit entries since the last report.
+	CreditEntries *EntriesSummary `parser:"(T90C @@ (CRLF|EOF))?" json:"tag90c,omitempty"`
+	// Specifies the book balance at the time of the report.
+	ClosingBalance Balance `parser:"T62F @@ (CRLF|EOF)" json:"tag62"`
+	// Indicates the funds which are available to the account owner (if credit balance)
+	// or the balance which is subject to interest charges (if debit balance).
+	ClosingAvailableBalance *Balance `parser:"(T64 @@ (CRLF|EOF))?" json:"tag64,omitempty"`
+	// Indicates the funds which are available to the account owner
+	// (if a credit or debit balance) for the specified forward value date.
+	ForwardAvailableBalance []Balance `parser:"(T65 @@ (CRLF|EOF))*" json:"tag65,omitempty"`
+	// Additional information for the account owner.
+	AccountOwnerInfo []string `parser:"(T86 @CharXSeq ((CRLF @CharXSeq?)*|EOF))?" json:"tag86,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT941Message) MessageType() string {
+	return "941"
+}
+
+// Validate validates MT941 messages according "Network Validated Rules"
+func (m MT941Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT941Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	if m.RelatedReference != nil {
+		if !isCorrectReference(*m.RelatedReference) {
+			return fmt.Errorf("bad related reference number: %s", *m.RelatedReference)
+		}
+	}
+
+	currencies := []string{}
+	balances := []Balance{}
+	if m.OpeningBalance != nil {
+		balances = append(balances, *m.OpeningBalance)
+	}
+	balances = append(balances, m.ClosingBalance)
+	if m.ClosingAvailableBalance != nil {
+		balances = append(balances, *m.ClosingAvailableBalance)
+	}
+	balances = append(balances, m.ForwardAvailableBalance...)
+	for _, b := range balances {
+		if err := b.Validate(cp); err != nil {
+			return fmt.Errorf("bad balance: %w", err)
+		}
+		currencies = append(currencies, b.Currency)
+	}
+
+	for _, es := range []*EntriesSummary{m.DebitEntries, m.CreditEntries} {
+		if es == nil {
+			continue
+		}
+		if err := es.Validate(cp); err != nil {
+			return fmt.Errorf("bad number and sum of entries: %w", err)
+		}
+		currencies = append(currencies, es.Currency)
+	}
+
+	// C1: the first two characters of the currency code in fields 60F, 90D, 90C, 62F, 64 and 65 must be the same.
+	return checkCurrencies(currencies...)
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT941Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Serialize formats message in MT941 format with CRLF line endings.
+func (m MT941Message) Serialize() string {
+	lines := []string{":20:" + m.TransactionRefNo}
+	if m.RelatedReference != nil {
+		lines = append(lines, ":21:"+*m.RelatedReference)
+	}
+	lines = append(lines, m.AccountIdentification.MTLines()...)
+	stmtNo := m.StatementNumber.StatementNo
+	if m.StatementNumber.SequenceNo != nil {
+		stmtNo += "/" + *m.StatementNumber.SequenceNo
+	}
+	lines = append(lines, ":28:"+stmtNo)
+	if m.DateTimeIndication != nil {
+		lines = append(lines, ":13D:"+m.DateTimeIndication.MTString())
+	}
+	if m.OpeningBalance != nil {
+		lines = append(lines, ":60F:"+m.OpeningBalance.MTString())
+	}
+	if m.DebitEntries != nil {
+		lines = append(lines, ":90D:"+m.DebitEntries.MTString())
+	}
+	if m.CreditEntries != nil {
+		lines = append(lines, ":90C:"+m.CreditEntries.MTString())
+	}
+	lines = append(lines, ":62F:"+m.ClosingBalance.MTString())
+	if m.ClosingAvailableBalance != nil {
+		lines = append(lines, ":64:"+m.ClosingAvailableBalance.MTString())
+	}
+	for _, fab := range m.ForwardAvailableBalance {
+		lines = append(lines, ":65:"+fab.MTString())
+	}
+	if len(m.AccountOwnerInfo) > 0 {
+		lines = append(lines, ":86:"+strings.Join(m.AccountOwnerInfo, parser.CRLF))
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
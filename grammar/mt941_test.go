@@ -0,0 +1,32 @@
+package grammar_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestMT941Validate(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT941Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt941", "input", "spec-example.sta"), true, nil)
+	assert.NoError(t, err)
+	m.CreditEntries.Currency = "USD"
+	assert.EqualError(t, m.Validate(), "currency USD differs from currency EUR (C1)")
+}
+
+func TestMT941EntryCount(t *testing.T) {
+	text := ":20:BR1\r\n:25:123\r\n:28:1\r\n:90D:00075EUR385920,\r\n:62F:C240531EUR1,\r\n"
+	m, err := parser.NewByteParser[grammar.MT941Message]().Parse([]byte(text), true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 75, m.DebitEntries.Count.Value)
+	assert.Equal(t, text, m.Serialize())
+	m.DebitEntries.Count = parser.NewCount(76)
+	assert.Contains(t, m.Serialize(), ":90D:76EUR385920,\r\n")
+
+	m.DebitEntries.Currency = ""
+	m.ClosingBalance.Currency = "E"
+	assert.Error(t, m.Validate())
+}
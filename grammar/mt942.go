@@ -0,0 +1,145 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT942 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// MT942Message represents MT942 (interim transaction report) standard message structure.
+type MT942Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// If the MT 942 is sent in response to an MT 920 Request Message, this field must contain the field 20 Transaction Reference Number of the request message.
+	RelatedReference *string `parser:"(T21 @CharXSeqSlashRestrict CRLF)?" json:"tag21,omitempty"`
+	// Identifies the account and optionally the identifier code of the account owner for which the report is sent.
+	AccountIdentification AccountIdent `parser:"(T25|T25P) @@ CRLF" json:"tag25"`
+	// Contains the sequential number of the report, optionally followed by the sequence number of the message
+	// within that report.
+	StatementNumber StatementNumber `parser:"T28C @@ CRLF" json:"tag28"`
+	// Specifies the floor limit for debit entries, or for debit and credit entries when the second one is not present,
+	// and the floor limit for credit entries.
+	FloorLimits []FloorLimit `parser:"(T34F @@ CRLF)+" json:"tag34f"`
+	// Indicates the date, time and time zone at which the report was created.
+	DateTimeIndication parser.DateTime `parser:"T13D @DateTimeInd (CRLF|EOF)" json:"tag13d"`
+	// Entries reported since the last report.
+	Statements []StatementSection `parser:"@@*" json:"statements,omitempty"`
+	// Indicates the total number and amount of debit entries.
+	DebitEntries *EntriesSummary `parser:"(T90D @@ (CRLF|EOF))?" json:"tag90d,omitempty"`
+	// Indicates the total number and amount of credit entries.
+	CreditEntries *EntriesSummary `parser:"(T90C @@ (CRLF|EOF))?" json:"tag90c,omitempty"`
+	// Additional information about the report as a whole.
+	AccountOwnerInfo []string `parser:"(T86 @CharXSeq ((CRLF @CharXSeq?)*|EOF))?" json:"tag86,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT942Message) MessageType() string {
+	return "942"
+}
+
+// Validate validates MT942 messages according "Network Validated Rules"
+func (m MT942Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT942Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+	sicp, err := v.statementIdentCodeProvider()
+	if err != nil {
+		return err
+	}
+
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	if m.RelatedReference != nil {
+		if !isCorrectReference(*m.RelatedReference) {
+			return fmt.Errorf("bad related reference number: %s", *m.RelatedReference)
+		}
+	}
+
+	if len(m.FloorLimits) > 2 {
+		return fmt.Errorf("too many floor limits: %d", len(m.FloorLimits))
+	}
+
+	currencies := []string{}
+	for _, fl := range m.FloorLimits {
+		if !cp.IsValid(fl.Currency) {
+			return fmt.Errorf("bad floor limit currency code: %s", fl.Currency)
+		}
+		currencies = append(currencies, fl.Currency)
+	}
+
+	// C2: debit/credit marks of the floor limits.
+	if err := checkFloorLimitMarks(m.FloorLimits); err != nil {
+		return err
+	}
+
+	for _, line := range m.Statements {
+		if err := line.Validate(sicp); err != nil {
+			return fmt.Errorf("error parsing MT942 statement: %w", err)
+		}
+	}
+
+	for _, es := range []*EntriesSummary{m.DebitEntries, m.CreditEntries} {
+		if es == nil {
+			continue
+		}
+		if err := es.Validate(cp); err != nil {
+			return fmt.Errorf("bad number and sum of entries: %w", err)
+		}
+		currencies = append(currencies, es.Currency)
+	}
+
+	// C1: the first two characters of the currency code in fields 34F, 90D and 90C must be the same.
+	return checkCurrencies(currencies...)
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT942Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Serialize formats message in MT942 format with CRLF line endings.
+func (m MT942Message) Serialize() string {
+	lines := []string{":20:" + m.TransactionRefNo}
+	if m.RelatedReference != nil {
+		lines = append(lines, ":21:"+*m.RelatedReference)
+	}
+	lines = append(lines, m.AccountIdentification.MTLines()...)
+	stmtNo := m.StatementNumber.StatementNo
+	if m.StatementNumber.SequenceNo != nil {
+		stmtNo += "/" + *m.StatementNumber.SequenceNo
+	}
+	lines = append(lines, ":28C:"+stmtNo)
+	for _, fl := range m.FloorLimits {
+		lines = append(lines, ":34F:"+fl.MTString())
+	}
+	lines = append(lines, ":13D:"+m.DateTimeIndication.MTString())
+	for _, stmt := range m.Statements {
+		lines = append(lines, stmt.MTLines()...)
+	}
+	if m.DebitEntries != nil {
+		lines = append(lines, ":90D:"+m.DebitEntries.MTString())
+	}
+	if m.CreditEntries != nil {
+		lines = append(lines, ":90C:"+m.CreditEntries.MTString())
+	}
+	if len(m.AccountOwnerInfo) > 0 {
+		lines = append(lines, ":86:"+strings.Join(m.AccountOwnerInfo, parser.CRLF))
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
@@ -0,0 +1,23 @@
+package grammar_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestMT942Validate(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT942Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt942", "input", "spec-example.sta"), true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(m.Statements))
+	assert.Equal(t, []string{"INTERIM REPORT"}, m.AccountOwnerInfo)
+	m.CreditEntries.Currency = "USD"
+	assert.EqualError(t, m.Validate(), "currency USD differs from currency EUR (C1)")
+	m.CreditEntries.Currency = "EUR"
+	m.FloorLimits = m.FloorLimits[:1]
+	assert.EqualError(t, m.Validate(), "single floor limit must not have debit/credit mark (C2)")
+}
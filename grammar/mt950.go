@@ -0,0 +1,139 @@
+package grammar
+
+import (
+	"fmt"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT950 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// StatementLine contains statement line without information to account owner (MT950).
+type StatementLine struct {
+	// Contains the details of each transaction.
+	Statement Statement `parser:"T61 @@ (CRLF|EOF)" json:"tag61"`
+}
+
+// MT950Message represents MT950 (statement message) standard message structure.
+type MT950Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Identifies the account for which the statement is sent.
+	AccountIdentification AccountIdent `parser:"T25 @@ CRLF" json:"tag25"`
+	// Contains the sequential number of the statement, optionally followed by the sequence number of the message
+	// within that statement when more than one message is sent for one statement.
+	StatementNumber StatementNumber `parser:"T28C @@ CRLF" json:"tag28"`
+	// Set when opening balance is intermediate (:60M:), i.e. message is not the first one of the statement.
+	IntermediateOpening bool `parser:"(T60F|@T60M)" json:"intermediate_opening,omitempty"`
+	// Specifies, for the (intermediate - M) opening balance, whether it is a debit or credit balance,
+	// the date, the currency and the amount of the balance.
+	OpeningBalance Balance `parser:"@@ (CRLF|EOF)" json:"tag60"`
+	// Statement lines, there is no information to account owner (:86:) in MT950.
+	Statements []StatementLine `parser:"@@*" json:"statements,omitempty"`
+	// Set when closing balance is intermediate (:62M:), i.e. statement continues in the next message.
+	IntermediateClosing bool `parser:"(T62F|@T62M)" json:"intermediate_closing,omitempty"`
+	// Specifies, for the (intermediate) closing balance.
+	ClosingBalance Balance `parser:"@@ (CRLF|EOF)" json:"tag62"`
+	// Indicates the funds which are available to the account owner (if credit balance)
+	// or the balance which is subject to interest charges (if debit balance).
+	ClosingAvailableBalance *Balance `parser:"(T64 @@ (CRLF|EOF))?" json:"tag64,omitempty"`
+	// Indicates the funds which are available to the account owner
+	// (if a credit or debit balance) for the specified forward value date.
+	ForwardAvailableBalance []Balance `parser:"(T65 @@ (CRLF|EOF))*" json:"tag65,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT950Message) MessageType() string {
+	return "950"
+}
+
+// Validate validates MT950 messages according "Network Validated Rules"
+func (m MT950Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT950Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+	sicp, err := v.statementIdentCodeProvider()
+	if err != nil {
+		return err
+	}
+
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	for _, line := range m.Statements {
+		if err := line.Statement.Validate(sicp); err != nil {
+			return fmt.Errorf("error parsing MT950 statement: %w", err)
+		}
+	}
+
+	balances := []Balance{m.OpeningBalance, m.ClosingBalance}
+	if m.ClosingAvailableBalance != nil {
+		balances = append(balances, *m.ClosingAvailableBalance)
+	}
+	balances = append(balances, m.ForwardAvailableBalance...)
+	for _, b := range balances {
+		if err := b.Validate(cp); err != nil {
+			return fmt.Errorf("bad balance: %w", err)
+		}
+	}
+
+	// C1: the first two characters of the currency code in fields 60a, 62a, 64 and 65 must be the same.
+	currencies := []string{}
+	for _, b := range balances {
+		currencies = append(currencies, b.Currency)
+	}
+	if err := checkCurrencies(currencies...); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT950Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// AsMT940 returns MT940 message with the same content, as MT950 fields are a subset of MT940 ones.
+// It allows to use MT940 exports for MT950 messages.
+func (m MT950Message) AsMT940() MT940Message {
+	result := MT940Message{
+		TransactionRefNo:        m.TransactionRefNo,
+		AccountIdentification:   m.AccountIdentification,
+		StatementNumber:         m.StatementNumber,
+		IntermediateOpening:     m.IntermediateOpening,
+		OpeningBalance:          m.OpeningBalance,
+		IntermediateClosing:     m.IntermediateClosing,
+		ClosingBalance:          m.ClosingBalance,
+		ClosingAvailableBalance: m.ClosingAvailableBalance,
+		ForwardAvailableBalance: m.ForwardAvailableBalance,
+		Extensible:              m.Extensible,
+	}
+	for _, line := range m.Statements {
+		result.Statements = append(result.Statements, StatementSection{Statement: line.Statement})
+	}
+	return result
+}
+
+// Serialize formats message in MT950 format with CRLF line endings.
+func (m MT950Message) Serialize() string {
+	return m.AsMT940().Serialize()
+}
+
+// ToCSV serializes message to CSV row set, with the same columns as MT940 one.
+// Columns of the fields not present in MT950 (21, 86) are empty.
+func (m MT950Message) ToCSV(serializeT65 bool) []string {
+	return m.AsMT940().ToCSV(serializeT65)
+}
@@ -0,0 +1,18 @@
+package grammar_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestMT950Validate(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT950Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt950", "input", "nostro.sta"), true, nil)
+	assert.NoError(t, err)
+	m.ForwardAvailableBalance[1].Currency = "EUR"
+	assert.EqualError(t, m.Validate(), "currency EUR differs from currency USD (C1)")
+}
@@ -0,0 +1,123 @@
+package grammar
+
+import (
+	"fmt"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT970 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// MT970Message represents MT970 (netting statement) standard message structure.
+type MT970Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Identifies the netting position for which the statement is sent.
+	AccountIdentification AccountIdent `parser:"T25 @@ CRLF" json:"tag25"`
+	// Contains the sequential number of the statement, optionally followed by the sequence number of the message
+	// within that statement when more than one message is sent for one statement.
+	StatementNumber StatementNumber `parser:"T28C @@ CRLF" json:"tag28"`
+	// Set when opening balance is intermediate (:60M:), i.e. message is not the first one of the statement.
+	IntermediateOpening bool `parser:"(T60F|@T60M)" json:"intermediate_opening,omitempty"`
+	// Specifies, for the (intermediate) opening balance, whether it is a debit or credit balance,
+	// the date, the currency and the amount of the balance.
+	OpeningBalance Balance `parser:"@@ (CRLF|EOF)" json:"tag60"`
+	// Statement lines, there is no information to account owner (:86:) in netting statements.
+	Statements []StatementLine `parser:"@@*" json:"statements,omitempty"`
+	// Set when closing balance is intermediate (:62M:), i.e. statement continues in the next message.
+	IntermediateClosing bool `parser:"(T62F|@T62M)" json:"intermediate_closing,omitempty"`
+	// Specifies, for the (intermediate) closing balance.
+	ClosingBalance Balance `parser:"@@ (CRLF|EOF)" json:"tag62"`
+	// Indicates the funds which are available to the account owner (if credit balance)
+	// or the balance which is subject to interest charges (if debit balance).
+	ClosingAvailableBalance *Balance `parser:"(T64 @@ (CRLF|EOF))?" json:"tag64,omitempty"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT970Message) MessageType() string {
+	return "970"
+}
+
+// Validate validates MT970 messages according "Network Validated Rules"
+func (m MT970Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT970Message) validate(v *Validator) error {
+	return m.validateAs(v, m.MessageType())
+}
+
+// validateAs validates the message as the message type, which is used in errors, e.g. 972 for MT972 messages.
+func (m MT970Message) validateAs(v *Validator, messageType string) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+	sicp, err := v.statementIdentCodeProvider()
+	if err != nil {
+		return err
+	}
+
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	for _, line := range m.Statements {
+		if err := line.Statement.Validate(sicp); err != nil {
+			return fmt.Errorf("error parsing MT%s statement: %w", messageType, err)
+		}
+	}
+
+	balances := []Balance{m.OpeningBalance, m.ClosingBalance}
+	if m.ClosingAvailableBalance != nil {
+		balances = append(balances, *m.ClosingAvailableBalance)
+	}
+	currencies := []string{}
+	for _, b := range balances {
+		if err := b.Validate(cp); err != nil {
+			return fmt.Errorf("bad balance: %w", err)
+		}
+		currencies = append(currencies, b.Currency)
+	}
+
+	// C1: the first two characters of the currency code in fields 60a, 62a and 64 must be the same.
+	return checkCurrencies(currencies...)
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT970Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// AsMT940 returns MT940 message with the same content, as MT970 fields are a subset of MT940 ones.
+// It allows to use MT940 exports for netting statements.
+func (m MT970Message) AsMT940() MT940Message {
+	return MT950Message{
+		TransactionRefNo:        m.TransactionRefNo,
+		AccountIdentification:   m.AccountIdentification,
+		StatementNumber:         m.StatementNumber,
+		IntermediateOpening:     m.IntermediateOpening,
+		OpeningBalance:          m.OpeningBalance,
+		Statements:              m.Statements,
+		IntermediateClosing:     m.IntermediateClosing,
+		ClosingBalance:          m.ClosingBalance,
+		ClosingAvailableBalance: m.ClosingAvailableBalance,
+		Extensible:              m.Extensible,
+	}.AsMT940()
+}
+
+// Serialize formats message in MT970 format with CRLF line endings.
+func (m MT970Message) Serialize() string {
+	return m.AsMT940().Serialize()
+}
+
+// ToCSV serializes message to CSV row set, with the same columns as MT940 one.
+func (m MT970Message) ToCSV(serializeT65 bool) []string {
+	return m.AsMT940().ToCSV(serializeT65)
+}
@@ -0,0 +1,79 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT971 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// NettingBalance is a balance of single netting position in MT971 message.
+type NettingBalance struct {
+	// Identifies the netting position for which the balance is reported.
+	Account string `parser:"T25 @CharXSeq CRLF" json:"tag25"`
+	// Specifies the final balance of the netting position.
+	ClosingBalance Balance `parser:"T62F @@ (CRLF|EOF)" json:"tag62"`
+}
+
+// MT971Message represents MT971 (netting balance report) standard message structure.
+type MT971Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Balances of the reported netting positions.
+	Balances []NettingBalance `parser:"@@+" json:"balances"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// MessageType returns SWIFT message type number.
+func (m MT971Message) MessageType() string {
+	return "971"
+}
+
+// Validate validates MT971 messages according "Network Validated Rules"
+func (m MT971Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT971Message) validate(v *Validator) error {
+	cp, err := v.currencyProvider()
+	if err != nil {
+		return err
+	}
+
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	if len(m.Balances) == 0 {
+		return fmt.Errorf("no balances in the message")
+	}
+
+	for _, nb := range m.Balances {
+		if err := nb.ClosingBalance.Validate(cp); err != nil {
+			return fmt.Errorf("bad balance of %s: %w", nb.Account, err)
+		}
+	}
+
+	return nil
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT971Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Serialize formats message in MT971 format with CRLF line endings.
+func (m MT971Message) Serialize() string {
+	lines := []string{":20:" + m.TransactionRefNo}
+	for _, nb := range m.Balances {
+		lines = append(lines, ":25:"+nb.Account, ":62F:"+nb.ClosingBalance.MTString())
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
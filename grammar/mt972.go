@@ -0,0 +1,43 @@
+package grammar
+
+// Grammar for MT972 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// MT972Message represents MT972 (netting interim statement) standard message structure,
+// which has the same fields as MT970 netting statement.
+type MT972Message MT970Message
+
+// MessageType returns SWIFT message type number.
+func (m MT972Message) MessageType() string {
+	return "972"
+}
+
+// Validate validates MT972 messages according "Network Validated Rules"
+func (m MT972Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message with reference data providers of the validator.
+func (m MT972Message) validate(v *Validator) error {
+	return MT970Message(m).validateAs(v, m.MessageType())
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT972Message) checkFormat() error {
+	return MT970Message(m).checkFormat()
+}
+
+// AsMT940 returns MT940 message with the same content, it allows to use MT940 exports for interim statements.
+func (m MT972Message) AsMT940() MT940Message {
+	return MT970Message(m).AsMT940()
+}
+
+// Serialize formats message in MT972 format with CRLF line endings.
+func (m MT972Message) Serialize() string {
+	return m.AsMT940().Serialize()
+}
+
+// ToCSV serializes message to CSV row set, with the same columns as MT940 one.
+func (m MT972Message) ToCSV(serializeT65 bool) []string {
+	return m.AsMT940().ToCSV(serializeT65)
+}
@@ -0,0 +1,82 @@
+package grammar
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/oswida/mt9x/parser"
+)
+
+// Grammar for MT973 file, according standard available here:
+// https://www2.swift.com/knowledgecentre/publications/us9m_20240719/2.0
+
+// NettingRequest is a single request of MT973 message.
+type NettingRequest struct {
+	// Identifies the message type which is being requested.
+	MessageRequested string `parser:"T12 @MsgType CRLF" json:"tag12"`
+	// Identifies the netting position for which the information is requested.
+	Account string `parser:"T25 @CharXSeq (CRLF|EOF)" json:"tag25"`
+}
+
+// MT973Message represents MT973 (netting request) standard message structure.
+type MT973Message struct {
+	// Specifies the reference assigned by the Sender to unambiguously identify the message.
+	TransactionRefNo string `parser:"T20 @CharXSeqSlashRestrict CRLF" json:"tag20"`
+	// Requested netting reports.
+	Requests []NettingRequest `parser:"@@+" json:"requests"`
+	// Non-standard fields of the message.
+	parser.Extensible
+	// Original text and position of the message.
+	parser.Source
+}
+
+// nettingRequestableTypes contains message types which can be requested with MT973.
+var nettingRequestableTypes = []string{"971", "972"}
+
+// MessageType returns SWIFT message type number.
+func (m MT973Message) MessageType() string {
+	return "973"
+}
+
+// Validate validates MT973 messages according "Network Validated Rules"
+func (m MT973Message) Validate() error {
+	return defaultValidator.Validate(m)
+}
+
+// validate validates the message, it does not use reference data providers.
+func (m MT973Message) validate(_ *Validator) error {
+	if !isCorrectReference(m.TransactionRefNo) {
+		return fmt.Errorf("bad transaction reference number: %s", m.TransactionRefNo)
+	}
+
+	if len(m.Requests) == 0 {
+		return fmt.Errorf("no requests in the message")
+	}
+
+	for i, r := range m.Requests {
+		if !slices.Contains(nettingRequestableTypes, r.MessageRequested) {
+			return fmt.Errorf("bad request %d: bad requested message type: %s, allowed types: %s",
+				i+1, r.MessageRequested, strings.Join(nettingRequestableTypes, ", "))
+		}
+		if r.Account == "" || len(r.Account) > 35 {
+			return fmt.Errorf("bad request %d: bad account identification: %s", i+1, r.Account)
+		}
+	}
+
+	return nil
+}
+
+// checkFormat checks lengths and line counts of the message fields.
+func (m MT973Message) checkFormat() error {
+	return checkMessageFormat(m.Source, m.Serialize)
+}
+
+// Serialize formats message in MT973 format with CRLF line endings.
+func (m MT973Message) Serialize() string {
+	lines := []string{":20:" + m.TransactionRefNo}
+	for _, r := range m.Requests {
+		lines = append(lines, ":12:"+r.MessageRequested, ":25:"+r.Account)
+	}
+	return parser.InsertExtensions(strings.Join(lines, parser.CRLF)+parser.CRLF, m.Extensions)
+}
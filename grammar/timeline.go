@@ -0,0 +1,168 @@
+package grammar
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// Kinds of account timeline issues.
+const (
+	// Statement numbers are not consecutive.
+	TimelineGap = "gap"
+	// Statement or its part was received more than once.
+	TimelineDuplicate = "duplicate"
+	// Closing balance of the statement differs from the opening balance of the next one.
+	TimelineBalanceMismatch = "balance_mismatch"
+	// Statement has missing parts or not matching intermediate balances.
+	TimelineIncomplete = "incomplete"
+	// Statement numbering restarts from 1, e.g. at the beginning of a year.
+	TimelineReset = "reset"
+)
+
+// TimelineIssue describes a continuity problem of account statements.
+type TimelineIssue struct {
+	Kind        string `json:"kind"`
+	StatementNo string `json:"stmt_number"`
+	// Statement preceding the one with the issue, if any.
+	PreviousStatementNo string `json:"previous_stmt_number,omitempty"`
+	Message             string `json:"message"`
+}
+
+// AccountTimeline contains ordered statements of one account with found continuity issues.
+type AccountTimeline struct {
+	Account    string               `json:"account"`
+	Statements []AssembledStatement `json:"statements"`
+	Issues     []TimelineIssue      `json:"issues,omitempty"`
+}
+
+// BuildTimelines assembles messages into statements and orders them per account by opening balance date
+// and statement number. Statement numbering restarting from 1 (e.g. at the beginning of a year) is reported
+// as a reset, not a gap, and statements with the same number before and after the reset are assembled
+// separately. Timelines are returned in order of account first occurrence.
+func BuildTimelines(messages ...MT940Message) ([]AccountTimeline, error) {
+	accounts := map[string]int{}
+	result := []AccountTimeline{}
+	grouped := [][]MT940Message{}
+	for i, m := range messages {
+		if _, err := strconv.Atoi(m.StatementNumber.StatementNo); err != nil {
+			return nil, fmt.Errorf("bad statement number of message %d: %w", i+1, err)
+		}
+		if seq, err := sequenceNumber(m); err != nil {
+			return nil, fmt.Errorf("bad sequence number of message %d: %w", i+1, err)
+		} else if seq < 1 {
+			return nil, fmt.Errorf("bad sequence number of message %d: %d is lower than 1", i+1, seq)
+		}
+		i, ok := accounts[m.AccountIdentification.Account]
+		if !ok {
+			i = len(result)
+			accounts[m.AccountIdentification.Account] = i
+			result = append(result, AccountTimeline{Account: m.AccountIdentification.Account})
+			grouped = append(grouped, nil)
+		}
+		grouped[i] = append(grouped[i], m)
+	}
+	for i := range result {
+		for _, epoch := range resetEpochs(grouped[i]) {
+			statements, err := AssembleStatements(epoch...)
+			if err != nil {
+				return nil, fmt.Errorf("account %s: %w", result[i].Account, err)
+			}
+			result[i].Statements = append(result[i].Statements, statements...)
+		}
+		if err := result[i].check(); err != nil {
+			return nil, fmt.Errorf("account %s: %w", result[i].Account, err)
+		}
+	}
+	return result, nil
+}
+
+// resetEpochs orders messages of one account by opening balance date, statement and sequence number
+// and splits them where the statement number drops below the highest one seen, i.e. where numbering restarts.
+// Later parts of statements already seen in the current epoch do not start a new one.
+// Statement and sequence numbers must be valid.
+func resetEpochs(messages []MT940Message) [][]MT940Message {
+	number := func(m MT940Message) int {
+		n, _ := strconv.Atoi(m.StatementNumber.StatementNo)
+		return n
+	}
+	sorted := slices.Clone(messages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].OpeningBalance.Date, sorted[j].OpeningBalance.Date
+		if !a.Equal(b.Time) {
+			return a.Before(b.Time)
+		}
+		if na, nb := number(sorted[i]), number(sorted[j]); na != nb {
+			return na < nb
+		}
+		sa, _ := sequenceNumber(sorted[i])
+		sb, _ := sequenceNumber(sorted[j])
+		return sa < sb
+	})
+	result := [][]MT940Message{}
+	highest := 0
+	// Statement numbers of the current epoch, later parts of a statement may have later opening dates.
+	numbers := map[int]bool{}
+	for _, m := range sorted {
+		n := number(m)
+		seq, _ := sequenceNumber(m)
+		if len(result) == 0 || n < highest && !(seq > 1 && numbers[n]) {
+			result = append(result, nil)
+			highest = 0
+			clear(numbers)
+		}
+		highest = max(highest, n)
+		numbers[n] = true
+		result[len(result)-1] = append(result[len(result)-1], m)
+	}
+	return result
+}
+
+func (t *AccountTimeline) check() error {
+	numbers := map[string]int{}
+	for _, s := range t.Statements {
+		n, err := strconv.Atoi(s.StatementNo)
+		if err != nil {
+			return fmt.Errorf("bad statement number %s: %w", s.StatementNo, err)
+		}
+		numbers[s.StatementNo] = n
+	}
+	sort.SliceStable(t.Statements, func(i, j int) bool {
+		a, b := t.Statements[i].Statement.OpeningBalance.Date, t.Statements[j].Statement.OpeningBalance.Date
+		if !a.Equal(b.Time) {
+			return a.Before(b.Time)
+		}
+		return numbers[t.Statements[i].StatementNo] < numbers[t.Statements[j].StatementNo]
+	})
+	for i, s := range t.Statements {
+		for _, p := range s.DuplicateParts {
+			t.Issues = append(t.Issues, TimelineIssue{Kind: TimelineDuplicate, StatementNo: s.StatementNo,
+				Message: fmt.Sprintf("statement %s part %d received more than once", s.StatementNo, p)})
+		}
+		if s.Incomplete() {
+			t.Issues = append(t.Issues, TimelineIssue{Kind: TimelineIncomplete, StatementNo: s.StatementNo,
+				Message: s.incompleteError().Error()})
+		}
+		if i == 0 {
+			continue
+		}
+		prev := t.Statements[i-1]
+		prevNo, no := numbers[prev.StatementNo], numbers[s.StatementNo]
+		switch {
+		case no == 1 && prevNo != 0:
+			t.Issues = append(t.Issues, TimelineIssue{Kind: TimelineReset, StatementNo: s.StatementNo, PreviousStatementNo: prev.StatementNo,
+				Message: fmt.Sprintf("statement numbering restarts after statement %s", prev.StatementNo)})
+		case no != prevNo+1:
+			t.Issues = append(t.Issues, TimelineIssue{Kind: TimelineGap, StatementNo: s.StatementNo, PreviousStatementNo: prev.StatementNo,
+				Message: fmt.Sprintf("statement %s follows statement %s", s.StatementNo, prev.StatementNo)})
+		}
+		closing, opening := prev.Statement.ClosingBalance, s.Statement.OpeningBalance
+		if closing.Currency != opening.Currency || !closing.SignedAmount().Equal(opening.SignedAmount()) {
+			t.Issues = append(t.Issues, TimelineIssue{Kind: TimelineBalanceMismatch, StatementNo: s.StatementNo, PreviousStatementNo: prev.StatementNo,
+				Message: fmt.Sprintf("opening balance %s %s of statement %s differs from closing balance %s %s of statement %s",
+					opening.SignedAmount(), opening.Currency, s.StatementNo, closing.SignedAmount(), closing.Currency, prev.StatementNo)})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package grammar_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+)
+
+func TestBuildTimelines(t *testing.T) {
+	part := func(number, seq, opening, closing string) grammar.MT940Message {
+		return statementPart(t, "DE89370400440532013000", number, seq, opening, "", closing)
+	}
+	other := statementPart(t, "GB29NWBK60161331926819", "7", "", ":60F:C240603GBP5,00", "", ":62F:C240603GBP5,00")
+	messages := []grammar.MT940Message{
+		part("12", "", ":60F:C240605EUR300,00", ":62F:C240605EUR250,00"),
+		other,
+		part("10", "", ":60F:C240603EUR100,00", ":62F:C240603EUR200,00"),
+		part("11", "", ":60F:C240604EUR200,00", ":62F:C240604EUR300,00"),
+		part("14", "1", ":60F:C240607EUR250,00", ":62M:C240607EUR250,00"),
+		part("10", "", ":60F:C240603EUR100,00", ":62F:C240603EUR200,00"),
+		part("1", "", ":60F:C240608EUR250,00", ":62F:C240608EUR250,00"),
+	}
+	result, err := grammar.BuildTimelines(messages...)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "GB29NWBK60161331926819", result[1].Account)
+	assert.Zero(t, result[1].Issues)
+	numbers := []string{}
+	for _, s := range result[0].Statements {
+		numbers = append(numbers, s.StatementNo)
+	}
+	assert.Equal(t, []string{"10", "11", "12", "14", "1"}, numbers)
+	assert.Equal(t, []grammar.TimelineIssue{
+		{Kind: grammar.TimelineDuplicate, StatementNo: "10", Message: "statement 10 part 1 received more than once"},
+		{Kind: grammar.TimelineIncomplete, StatementNo: "14", Message: "statement 14 of account DE89370400440532013000: missing parts 2"},
+		{Kind: grammar.TimelineGap, StatementNo: "14", PreviousStatementNo: "12", Message: "statement 14 follows statement 12"},
+		{Kind: grammar.TimelineReset, StatementNo: "1", PreviousStatementNo: "14", Message: "statement numbering restarts after statement 14"},
+	}, result[0].Issues)
+
+	messages[0].OpeningBalance.DCMark = grammar.Debit
+	result, err = grammar.BuildTimelines(messages...)
+	assert.NoError(t, err)
+	assert.Equal(t, grammar.TimelineIssue{Kind: grammar.TimelineBalanceMismatch, StatementNo: "12", PreviousStatementNo: "11",
+		Message: "opening balance -300 EUR of statement 12 differs from closing balance 300 EUR of statement 11"}, result[0].Issues[1])
+
+	// Statements with the same number after numbering reset are not duplicates.
+	result, err = grammar.BuildTimelines(
+		part("1", "", ":60F:C250101EUR100,00", ":62F:C250101EUR100,00"),
+		part("2", "1", ":60F:C250102EUR100,00", ":62M:C250103EUR100,00"),
+		part("1", "", ":60F:C260101EUR100,00", ":62F:C260101EUR100,00"),
+		part("2", "2", ":60M:C250103EUR100,00", ":62F:C250103EUR100,00"),
+	)
+	assert.NoError(t, err)
+	numbers = []string{}
+	for _, s := range result[0].Statements {
+		numbers = append(numbers, s.StatementNo)
+		assert.NoError(t, s.Validate())
+	}
+	assert.Equal(t, []string{"1", "2", "1"}, numbers)
+	assert.Equal(t, "2026-01-01", result[0].Statements[2].Statement.OpeningBalance.Date.Format("2006-01-02"))
+	assert.Equal(t, []grammar.TimelineIssue{
+		{Kind: grammar.TimelineReset, StatementNo: "1", PreviousStatementNo: "2", Message: "statement numbering restarts after statement 2"},
+	}, result[0].Issues)
+}
@@ -0,0 +1,226 @@
+package grammar
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transaction is a normalized statement line with the data of its message,
+// one record per transaction regardless of the message structure.
+type Transaction struct {
+	TransactionRefNo string `json:"transaction_ref"`
+	Account          string `json:"account"`
+	// Currency of the account, taken from the opening balance.
+	Currency    string `json:"currency"`
+	StatementNo string `json:"stmt_number"`
+	SequenceNo  string `json:"seq_number,omitempty"`
+	// Position of the statement line in the message, starting from 0.
+	Index     int       `json:"index"`
+	ValueDate time.Time `json:"value_date"`
+	// Entry date with the year inferred from the value date, if present in the statement line.
+	EntryDate *time.Time `json:"entry_date,omitempty"`
+	// Entry date if present, value date otherwise.
+	BookingDate time.Time       `json:"booking_date"`
+	DCMark      DCMark          `json:"dc_mark"`
+	Reversal    bool            `json:"reversal,omitempty"`
+	FundsCode   string          `json:"funds_code,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+	// Amount with sign of the balance change, negative for debits and reversals of credits.
+	SignedAmount     decimal.Decimal `json:"signed_amount"`
+	TransactionIdent string          `json:"trx_ident"`
+	// Description and category of the SWIFT transaction type code, empty for other codes.
+	TransactionDescription string `json:"trx_description,omitempty"`
+	TransactionCategory    string `json:"trx_category,omitempty"`
+	Reference              string `json:"owner_ref"`
+	InstitutionReference   string `json:"institution_ref,omitempty"`
+	Details                string `json:"details,omitempty"`
+	// Original lines of information to account owner (field 86).
+	AccountOwnerInfo []string `json:"tag86,omitempty"`
+	// Decoded information to account owner.
+	OwnerInfo OwnerInfo `json:"owner_info"`
+}
+
+// Transactions returns flattened transaction records of the message statement lines.
+func (m MT940Message) Transactions() []Transaction {
+	result := make([]Transaction, len(m.Statements))
+	for i, ss := range m.Statements {
+		s := ss.Statement
+		t := Transaction{
+			TransactionRefNo:     m.TransactionRefNo,
+			Account:              m.AccountIdentification.Account,
+			Currency:             m.OpeningBalance.Currency,
+			StatementNo:          m.StatementNumber.StatementNo,
+			SequenceNo:           orEmptyString(m.StatementNumber.SequenceNo),
+			Index:                i,
+			ValueDate:            s.ValueDate.Time,
+			BookingDate:          s.BookingDate(),
+			DCMark:               s.DCMark,
+			Reversal:             s.IsReversal(),
+			FundsCode:            orEmptyString(s.FundsCode),
+			Amount:               s.Amount.Decimal,
+			SignedAmount:         s.SignedAmount(),
+			TransactionIdent:     s.TransactionIdent,
+			Reference:            s.Reference,
+			InstitutionReference: orEmptyString(s.InstitutionReference),
+			Details:              orEmptyString(s.Details),
+			AccountOwnerInfo:     ss.AccountOwnerInfo,
+			OwnerInfo:            DecodeOwnerInfo(ss.AccountOwnerInfo),
+		}
+		if info, ok := s.SWIFTTransactionType(); ok {
+			t.TransactionDescription, t.TransactionCategory = info.Description, info.Category
+		}
+		if s.EntryDate != nil {
+			entryDate := t.BookingDate
+			t.EntryDate = &entryDate
+		}
+		result[i] = t
+	}
+	return result
+}
+
+// Transactions returns flattened transaction records of the MT950 message statement lines.
+func (m MT950Message) Transactions() []Transaction {
+	return m.AsMT940().Transactions()
+}
+
+// Transactions returns flattened transaction records of the MT970 message statement lines.
+func (m MT970Message) Transactions() []Transaction {
+	return m.AsMT940().Transactions()
+}
+
+// Transactions returns flattened transaction records of the MT972 message statement lines.
+func (m MT972Message) Transactions() []Transaction {
+	return m.AsMT940().Transactions()
+}
+
+// Transactions returns flattened transaction records of all messages, in the order of messages.
+func Transactions(messages ...MT940Message) []Transaction {
+	result := []Transaction{}
+	for _, m := range messages {
+		result = append(result, m.Transactions()...)
+	}
+	return result
+}
+
+// Formats of information to account owner.
+const (
+	// Structured with ?NN subfields preceded by transaction code (GVC), used e.g. by German and Czech banks.
+	OwnerInfoSubfields = "subfields"
+	// Structured with /CODE/ keywords, e.g. /EREF/, /REMI/, /ORDP/.
+	OwnerInfoKeywords = "keywords"
+	// Unstructured text.
+	OwnerInfoText = "text"
+)
+
+// OwnerInfo is decoded information to account owner (field 86).
+type OwnerInfo struct {
+	Format string `json:"format"`
+	// Transaction code preceding subfields, e.g. 166.
+	Code string `json:"code,omitempty"`
+	// Values of subfields (e.g. "20") or keywords (e.g. "REMI"), values of repeated ones are joined.
+	Fields              map[string]string `json:"fields,omitempty"`
+	Purpose             string            `json:"purpose,omitempty"`
+	EndToEndReference   string            `json:"end_to_end_ref,omitempty"`
+	CounterpartyName    string            `json:"counterparty_name,omitempty"`
+	CounterpartyAccount string            `json:"counterparty_account,omitempty"`
+	CounterpartyBank    string            `json:"counterparty_bank,omitempty"`
+	// Whole content as a single line.
+	Text string `json:"text,omitempty"`
+}
+
+var (
+	subfieldsStart = regexp.MustCompile(`^([0-9]{3})?\?[0-9]{2}`)
+	subfield       = regexp.MustCompile(`\?([0-9]{2})`)
+	// Keywords of structured information, other /XXX/ sequences are a part of values.
+	keyword = regexp.MustCompile(`/(EREF|KREF|MREF|CRED|DEBT|REMI|PURP|ORDP|BENM|ULTD|ULTC|ORDB|BENB|NAME|ADDR|IBAN|ACCW|BIC|CHGS|RTRN|OCMT|TRCD|CSID|EXCH|SVCL|CDTRREFTP|CDTRREF|CODE|ISSR)/`)
+	sepaKey = regexp.MustCompile(`(EREF|KREF|MREF|CRED|DEBT|SVWZ|ABWA|ABWE|IBAN|BIC)\+`)
+)
+
+// DecodeOwnerInfo decodes content of information to account owner (field 86).
+func DecodeOwnerInfo(lines []string) OwnerInfo {
+	// Lines of structured content are wrapped at any character, so they are joined without separator.
+	joined := strings.Join(lines, "")
+	switch {
+	case subfieldsStart.MatchString(joined):
+		return decodeSubfields(joined)
+	case strings.HasPrefix(joined, "/") && keyword.MatchString(joined):
+		return decodeKeywords(joined)
+	}
+	text := strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+	return OwnerInfo{Format: OwnerInfoText, Purpose: text, Text: text}
+}
+
+// splitFields splits text at positions of keys matched by the expression, text before the first key is skipped.
+func splitFields(text string, key *regexp.Regexp) map[string]string {
+	result := map[string]string{}
+	matches := key.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		name := text[m[2]:m[3]]
+		result[name] += text[m[1]:end]
+	}
+	return result
+}
+
+func decodeSubfields(text string) OwnerInfo {
+	fields := splitFields(text, subfield)
+	result := OwnerInfo{
+		Format:              OwnerInfoSubfields,
+		Code:                subfieldsStart.FindStringSubmatch(text)[1],
+		Fields:              fields,
+		CounterpartyBank:    strings.TrimSpace(fields["30"]),
+		CounterpartyAccount: strings.TrimSpace(fields["31"]),
+		CounterpartyName:    normalizeSpaces(fields["32"] + fields["33"]),
+	}
+	purpose := ""
+	for _, key := range []string{"20", "21", "22", "23", "24", "25", "26", "27", "28", "29", "60", "61", "62", "63"} {
+		purpose += fields[key]
+	}
+	// SEPA transactions have purpose structured with keys, e.g. EREF+ and SVWZ+.
+	if sepa := splitFields(purpose, sepaKey); len(sepa) > 0 {
+		result.EndToEndReference = strings.TrimSpace(sepa["EREF"])
+		if svwz, ok := sepa["SVWZ"]; ok {
+			purpose = svwz
+		}
+	}
+	result.Purpose = normalizeSpaces(purpose)
+	result.Text = normalizeSpaces(subfield.ReplaceAllString(text, " "))
+	return result
+}
+
+func decodeKeywords(text string) OwnerInfo {
+	fields := splitFields(text, keyword)
+	for k, v := range fields {
+		fields[k] = strings.TrimSuffix(v, "/")
+	}
+	result := OwnerInfo{
+		Format:              OwnerInfoKeywords,
+		Fields:              fields,
+		Purpose:             normalizeSpaces(fields["REMI"]),
+		EndToEndReference:   strings.TrimSpace(fields["EREF"]),
+		CounterpartyName:    normalizeSpaces(firstNonEmpty(fields["NAME"], fields["ORDP"], fields["BENM"])),
+		CounterpartyAccount: strings.TrimSpace(firstNonEmpty(fields["IBAN"], fields["ACCW"])),
+		CounterpartyBank:    strings.TrimSpace(firstNonEmpty(fields["BIC"], fields["ORDB"], fields["BENB"])),
+		Text:                normalizeSpaces(text),
+	}
+	return result
+}
+
+func normalizeSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package grammar_test
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+func TestTransactions(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	basePath := filepath.Join("..", "parser", "testdata", "mt940", "input")
+	sepa, err := fp.Parse(filepath.Join(basePath, "sepa.sta"), false, nil)
+	assert.NoError(t, err)
+	payments, err := fp.Parse(filepath.Join(basePath, "io-payments.sta"), false, nil)
+	assert.NoError(t, err)
+	example, err := fp.Parse(filepath.Join(basePath, "spec-example-1.sta"), false, nil)
+	assert.NoError(t, err)
+	transactions := grammar.Transactions(*sepa, *payments, *example)
+	assert.Equal(t, len(sepa.Statements)+len(payments.Statements)+len(example.Statements), len(transactions))
+
+	first := transactions[0]
+	assert.Equal(t, sepa.AccountIdentification.Account, first.Account)
+	assert.Equal(t, "EUR", first.Currency)
+	assert.Equal(t, grammar.OwnerInfoSubfields, first.OwnerInfo.Format)
+	assert.Equal(t, "166", first.OwnerInfo.Code)
+	assert.Equal(t, "TFNR 21001 EndToEndId 00001", first.OwnerInfo.EndToEndReference)
+	assert.Equal(t, "Verwend CTSc-01 FFP TFNr 21 001", first.OwnerInfo.Purpose)
+	assert.Equal(t, "QUENTIN QUAST", first.OwnerInfo.CounterpartyName)
+	assert.Equal(t, "DRESDEFF508", first.OwnerInfo.CounterpartyBank)
+
+	gbp := transactions[len(sepa.Statements)]
+	assert.Equal(t, "GBP", gbp.Currency)
+	assert.Equal(t, grammar.OwnerInfoKeywords, gbp.OwnerInfo.Format)
+	assert.Equal(t, "1177000222", gbp.OwnerInfo.Purpose)
+	assert.Equal(t, "COMPANY UK LTD", gbp.OwnerInfo.CounterpartyName)
+	assert.Equal(t, "SC208801", gbp.OwnerInfo.CounterpartyBank)
+	assert.Equal(t, "SHA", gbp.OwnerInfo.Fields["CHGS"])
+
+	remi := transactions[len(sepa.Statements)+len(payments.Statements)+1]
+	assert.Equal(t, "/INV/78541", remi.OwnerInfo.Purpose)
+	assert.Equal(t, "COMPUTERSYS INC.", remi.OwnerInfo.CounterpartyName)
+	text := transactions[len(transactions)-1]
+	assert.Equal(t, grammar.OwnerInfoText, text.OwnerInfo.Format)
+	assert.Equal(t, text.SignedAmount.String(), text.Amount.String())
+	assert.Equal(t, "DIVIDEND LORAL CORP PREFERRED STOCK 3TH QUARTER 2017", text.OwnerInfo.Text)
+	assert.Zero(t, text.EntryDate)
+	assert.Equal(t, first.ValueDate, *first.EntryDate)
+	first.BookingDate = time.Time{}
+	assert.Equal(t, first.ValueDate, *first.EntryDate)
+
+	// CSV keeps entry date without year and adds booking date as the last column.
+	m, err := fp.Parse(filepath.Join(basePath, "entry-date.sta"), false, nil)
+	assert.NoError(t, err)
+	rows := m.ToCSV(false)
+	header := strings.Split(rows[0], ",")
+	row := strings.Split(rows[1], ",")
+	assert.Equal(t, "EntryDate", header[slices.Index(header, "ValueDate")+1])
+	assert.Equal(t, "0000-02-24", row[slices.Index(header, "EntryDate")])
+	assert.Equal(t, "2009-02-24", row[slices.Index(header, "BookingDate")])
+	assert.Equal(t, []string{"BookingDate", "TrxDescription", "TrxCategory"}, header[len(header)-3:])
+	assert.Equal(t, []string{"SWIFT MT202", "SWIFT message"}, row[len(row)-2:])
+}
@@ -0,0 +1,120 @@
+package grammar
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/oswida/mt9x/bundle"
+	"github.com/oswida/mt9x/parser"
+)
+
+// CurrencyProvider checks currency codes, e.g. *bundle.CurrencyProvider.
+type CurrencyProvider interface {
+	IsValid(code string) bool
+}
+
+// StatementIdentCodeProvider checks statement identification codes given without the transaction type letter,
+// e.g. *bundle.StatementIdentCodeProvider.
+type StatementIdentCodeProvider interface {
+	IsProperCode(code string) bool
+}
+
+// Validator validates messages using reference data providers shared by all validations,
+// providers are loaded once when needed. It is safe for concurrent use.
+type Validator struct {
+	currencies   func() (CurrencyProvider, error)
+	codes        func() (StatementIdentCodeProvider, error)
+	formatChecks bool
+}
+
+// ValidatorOption configures the validator.
+type ValidatorOption func(*Validator)
+
+// WithCurrencyProvider sets custom currency provider.
+func WithCurrencyProvider(p CurrencyProvider) ValidatorOption {
+	return func(v *Validator) {
+		v.currencies = func() (CurrencyProvider, error) { return p, nil }
+	}
+}
+
+// WithStatementIdentCodeProvider sets custom statement identification code provider.
+func WithStatementIdentCodeProvider(p StatementIdentCodeProvider) ValidatorOption {
+	return func(v *Validator) {
+		v.codes = func() (StatementIdentCodeProvider, error) { return p, nil }
+	}
+}
+
+// WithReferenceData makes the validator load its own bundle providers with the options, e.g. with historic currencies.
+func WithReferenceData(opts ...bundle.Option) ValidatorOption {
+	return func(v *Validator) {
+		v.currencies = sync.OnceValues(func() (CurrencyProvider, error) { return bundle.NewCurrencyProvider(opts...) })
+		v.codes = sync.OnceValues(func() (StatementIdentCodeProvider, error) {
+			return bundle.NewStatementIdentificationCodeProvider(opts...)
+		})
+	}
+}
+
+// WithFormatChecks makes the validator check lengths and line counts of the message fields.
+func WithFormatChecks() ValidatorOption {
+	return func(v *Validator) {
+		v.formatChecks = true
+	}
+}
+
+// NewValidator creates message validator, shared bundle providers with default options are used
+// unless other providers are set.
+func NewValidator(opts ...ValidatorOption) *Validator {
+	v := &Validator{
+		currencies: func() (CurrencyProvider, error) { return bundle.DefaultCurrencyProvider() },
+		codes:      func() (StatementIdentCodeProvider, error) { return bundle.DefaultStatementIdentCodeProvider() },
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// defaultValidator is used by Validate methods of the messages.
+var defaultValidator = NewValidator()
+
+// validatable is implemented by messages validated with reference data providers.
+type validatable interface {
+	validate(v *Validator) error
+}
+
+// formatCheckable is implemented by messages with field length and line count constraints.
+type formatCheckable interface {
+	checkFormat() error
+}
+
+// Validate validates the message according "Network Validated Rules", then checks lengths
+// and line counts of its fields when the validator is created with WithFormatChecks.
+func (v *Validator) Validate(m parser.MT9xMessage) error {
+	vm, ok := m.(validatable)
+	if !ok {
+		return m.Validate()
+	}
+	if err := vm.validate(v); err != nil {
+		return err
+	}
+	if fm, ok := m.(formatCheckable); ok && v.formatChecks {
+		return fm.checkFormat()
+	}
+	return nil
+}
+
+func (v *Validator) currencyProvider() (CurrencyProvider, error) {
+	cp, err := v.currencies()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create currency provider: %w", err)
+	}
+	return cp, nil
+}
+
+func (v *Validator) statementIdentCodeProvider() (StatementIdentCodeProvider, error) {
+	sicp, err := v.codes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create statement identification provider: %w", err)
+	}
+	return sicp, nil
+}
@@ -0,0 +1,40 @@
+package grammar_test
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/bundle"
+)
+
+func TestReferenceData(t *testing.T) {
+	fsys := fstest.MapFS{
+		bundle.CurrencyFile:           {Data: []byte(`<ISO_4217 Pblshd="2025-01-01"><CcyTbl><CcyNtry><CtryNm>ZIMBABWE</CtryNm><CcyNm>Zimbabwe Gold</CcyNm><Ccy>ZWG</Ccy><CcyNbr>924</CcyNbr><CcyMnrUnts>2</CcyMnrUnts></CcyNtry></CcyTbl></ISO_4217>`)},
+		bundle.StatementIdentCodeFile: {Data: []byte("TRF,Credit transfer,Transfer\nXYZ,Local code\n")},
+	}
+	cp, err := bundle.NewCurrencyProvider(bundle.WithFS(fsys))
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-01", cp.Published)
+	assert.True(t, slices.Contains(cp.List(), "ZWG"))
+	assert.True(t, slices.Contains(cp.List(), "PLN"))
+	assert.False(t, slices.Contains(cp.List(), "DEM"))
+	sicp, err := bundle.NewStatementIdentificationCodeProvider(bundle.WithFS(fsys))
+	assert.NoError(t, err)
+	assert.Equal(t, "Credit transfer", sicp.Codes["TRF"])
+	assert.True(t, sicp.IsProperCode("XYZ"))
+	assert.True(t, sicp.IsProperCode("CHG"))
+
+	m := statementPart(t, "DE89370400440532013000", "1", "", ":60F:C011231DEM100,00", ":61:0201020102D10,00NTRFNONREF\r\n", ":62F:C020102DEM90,00")
+	assert.EqualError(t, m.Validate(), "bad opening balance: bad currency code: DEM")
+	bundle.SetDefaultOptions(bundle.WithHistoricCurrencies(), bundle.WithFS(fsys))
+	t.Cleanup(func() { bundle.SetDefaultOptions() })
+	assert.NoError(t, m.Validate())
+	cp, err = bundle.DefaultCurrencyProvider()
+	assert.NoError(t, err)
+	assert.True(t, cp.IsHistoric("DEM"))
+	assert.False(t, cp.IsHistoric("EUR"))
+	info, _ := m.Statements[0].Statement.TransactionType()
+	assert.Equal(t, "Credit transfer", info.Description)
+}
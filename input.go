@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/oswida/mt9x/export"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+)
+
+const (
+	stdinName       = "-"
+	autoMessageType = "auto"
+)
+
+// input is a single named message source.
+type input struct {
+	name string
+	data []byte
+}
+
+// readInputs expands glob patterns and reads all inputs, "-" (or no arguments) means standard input.
+// Every entry of zip archive is a separate input.
+func readInputs(args []string, stdin io.Reader) ([]input, error) {
+	if len(args) == 0 {
+		args = []string{stdinName}
+	}
+	result := []input{}
+	for _, arg := range args {
+		if arg == stdinName {
+			data, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read standard input: %w", err)
+			}
+			result = append(result, input{name: stdinName, data: data})
+			continue
+		}
+		names, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("bad file pattern %s: %w", arg, err)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+			}
+			entries, err := parser.ReadArchive(name, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+			}
+			for _, e := range entries {
+				if e.Zipped {
+					result = append(result, input{name: name + ":" + e.Name, data: e.Data})
+				} else {
+					result = append(result, input{name: name, data: e.Data})
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// converter writes messages of a single type in other format.
+type converter func(w io.Writer, messages []parser.MT9xMessage, rules *export.JournalRules) error
+
+// messageKind groups operations available for a single MT message type.
+type messageKind struct {
+	parse      func(data []byte, trace io.Writer) (parser.MT9xMessage, error)
+	batch      func(ctx context.Context, dir string, opts parser.BatchOptions) ([]parser.FileSummary, error)
+	converters map[string]converter
+}
+
+// newMessageKind creates parse operations for the message type.
+func newMessageKind[T parser.MT9xMessage](converters map[string]converter) messageKind {
+	bp := parser.NewByteParser[T]()
+	return messageKind{
+		parse: func(data []byte, trace io.Writer) (parser.MT9xMessage, error) {
+			m, err := bp.Parse(data, false, trace)
+			if err != nil {
+				return nil, err
+			}
+			return *m, nil
+		},
+		batch: func(ctx context.Context, dir string, opts parser.BatchOptions) ([]parser.FileSummary, error) {
+			results, err := parser.NewBatchParser[T]().ParseDir(ctx, dir, opts)
+			summaries := make([]parser.FileSummary, len(results))
+			for i, r := range results {
+				summaries[i] = r.FileSummary
+				summaries[i].Path = filepath.Join(dir, filepath.FromSlash(r.Path))
+			}
+			return summaries, err
+		},
+		converters: converters,
+	}
+}
+
+// statementMessages converts statement messages to MT940 ones, as MT950, MT970 and MT972 fields are a subset of MT940 ones.
+func statementMessages(messages []parser.MT9xMessage) ([]grammar.MT940Message, error) {
+	result := make([]grammar.MT940Message, len(messages))
+	for i, m := range messages {
+		switch v := m.(type) {
+		case grammar.MT940Message:
+			result[i] = v
+		case grammar.MT950Message:
+			result[i] = v.AsMT940()
+		case grammar.MT970Message:
+			result[i] = v.AsMT940()
+		case grammar.MT972Message:
+			result[i] = v.AsMT940()
+		default:
+			return nil, fmt.Errorf("MT%s message is not a statement", parser.MessageType(m))
+		}
+	}
+	return result, nil
+}
+
+// statementConverter adapts MT940 export function to converter.
+func statementConverter(write func(w io.Writer, messages ...grammar.MT940Message) error) converter {
+	return func(w io.Writer, messages []parser.MT9xMessage, _ *export.JournalRules) error {
+		statements, err := statementMessages(messages)
+		if err != nil {
+			return err
+		}
+		return write(w, statements...)
+	}
+}
+
+// journalConverter adapts journal export to converter.
+func journalConverter(format export.JournalFormat) converter {
+	return func(w io.Writer, messages []parser.MT9xMessage, rules *export.JournalRules) error {
+		statements, err := statementMessages(messages)
+		if err != nil {
+			return err
+		}
+		return export.WriteJournal(w, format, rules, statements...)
+	}
+}
+
+// statementConverters returns converters available for statement messages.
+func statementConverters() map[string]converter {
+	return map[string]converter{
+		"csv":       statementConverter(writeMT940CSV),
+		"camt053":   statementConverter(export.WriteCamt053),
+		"ofx":       statementConverter(export.WriteOFX),
+		"mt940":     statementConverter(writeMT940),
+		"beancount": journalConverter(export.Beancount),
+		"ledger":    journalConverter(export.Ledger),
+	}
+}
+
+// writeMT940CSV writes all messages as a single CSV document.
+func writeMT940CSV(w io.Writer, messages ...grammar.MT940Message) error {
+	for i, m := range messages {
+		rows := m.ToCSV(true)
+		if i > 0 {
+			rows = rows[1:]
+		}
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(w, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeMT940 writes messages in MT940 format, one after another.
+func writeMT940(w io.Writer, messages ...grammar.MT940Message) error {
+	for _, m := range messages {
+		if _, err := io.WriteString(w, m.Serialize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serializeConverter writes messages in their own MT format, one after another.
+func serializeConverter[T interface{ Serialize() string }]() converter {
+	return func(w io.Writer, messages []parser.MT9xMessage, _ *export.JournalRules) error {
+		for _, m := range messages {
+			if _, err := io.WriteString(w, m.(T).Serialize()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// messageKinds contains supported message types indexed by the type number.
+var messageKinds = map[string]func() messageKind{
+	"940": func() messageKind {
+		return newMessageKind[grammar.MT940Message](statementConverters())
+	},
+	"950": func() messageKind {
+		return newMessageKind[grammar.MT950Message](statementConverters())
+	},
+	"941": func() messageKind {
+		return newMessageKind[grammar.MT941Message](map[string]converter{
+			"mt941": serializeConverter[grammar.MT941Message](),
+		})
+	},
+	"942": func() messageKind {
+		return newMessageKind[grammar.MT942Message](map[string]converter{
+			"mt942": serializeConverter[grammar.MT942Message](),
+		})
+	},
+	"900": func() messageKind {
+		return newMessageKind[grammar.MT900Message](map[string]converter{
+			"mt900": serializeConverter[grammar.MT900Message](),
+		})
+	},
+	"910": func() messageKind {
+		return newMessageKind[grammar.MT910Message](map[string]converter{
+			"mt910": serializeConverter[grammar.MT910Message](),
+		})
+	},
+	"920": func() messageKind {
+		return newMessageKind[grammar.MT920Message](map[string]converter{
+			"mt920": serializeConverter[grammar.MT920Message](),
+		})
+	},
+	"970": func() messageKind {
+		return newMessageKind[grammar.MT970Message](statementConverters())
+	},
+	"971": func() messageKind {
+		return newMessageKind[grammar.MT971Message](map[string]converter{
+			"mt971": serializeConverter[grammar.MT971Message](),
+		})
+	},
+	"972": func() messageKind {
+		return newMessageKind[grammar.MT972Message](statementConverters())
+	},
+	"973": func() messageKind {
+		return newMessageKind[grammar.MT973Message](map[string]converter{
+			"mt973": serializeConverter[grammar.MT973Message](),
+		})
+	},
+}
+
+// autoKind detects type of every message, only statement messages can be converted.
+func autoKind() messageKind {
+	return messageKind{
+		parse: func(data []byte, trace io.Writer) (parser.MT9xMessage, error) {
+			return grammar.ParseMessage(data, false, trace)
+		},
+		converters: statementConverters(),
+	}
+}
+
+// kindOf returns operations for the message type, "auto" detects the type of every message.
+func kindOf(messageType string) (messageKind, error) {
+	if messageType == autoMessageType {
+		return autoKind(), nil
+	}
+	kind, ok := messageKinds[messageType]
+	if !ok {
+		return messageKind{}, fmt.Errorf("unsupported message type: %s", messageType)
+	}
+	return kind(), nil
+}
@@ -1,23 +1,58 @@
+// Command mt9x parses, validates and converts SWIFT MT9x messages.
+//
+// Usage:
+//
+//	mt9x parse [--format json|yaml] [--validate] [flags] [file|glob|-]...
+//	mt9x validate [--report text|json] [flags] [file|glob|-]...
+//	mt9x batch [--workers n] [--pattern glob] [--validate] [--report text|json] [flags] dir...
+//	mt9x convert --to csv|camt053|ofx|mt940|beancount|ledger [--rules file] [flags] [file|glob|-]...
+//
+// MT900, MT910, MT920, MT941, MT942, MT971 and MT973 messages can be converted only to their own MT format, e.g. mt941.
+// Common flags are --message-type (default 940, auto detects the type of every message) and --trace, which writes parser trace to stderr.
+// Standard input is read when no file is given or the file name is "-".
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-
-	"github.com/oswida/mt9x/grammar"
-	"github.com/oswida/mt9x/parser"
+	"io"
+	"os"
 )
 
+const usage = `usage: mt9x <command> [flags] [file|glob|-]...
+
+Commands:
+  parse     parse messages and print them as JSON or YAML
+  validate  parse and validate messages, exit with non-zero code on failure
+  convert   convert messages to other formats
+  batch     parse all files in directories concurrently and print summary
+
+Run 'mt9x <command> -h' for command flags.
+`
+
 func main() {
-	p := parser.NewFileParser[grammar.MT940Message]()
-	result, err := p.Parse("parser/testdata/mt940/input/csob.sta", false, nil)
-	if err != nil {
-		panic(err)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes command line and returns process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
 	}
-	v, err := json.MarshalIndent(result, "", " ")
-	if err != nil {
-		panic(err)
+	env := &environment{stdin: stdin, stdout: stdout, stderr: stderr}
+	switch args[0] {
+	case "parse":
+		return env.parseCommand(args[1:])
+	case "validate":
+		return env.validateCommand(args[1:])
+	case "convert":
+		return env.convertCommand(args[1:])
+	case "batch":
+		return env.batchCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
 	}
-
-	fmt.Printf("%s\n", string(v))
+	fmt.Fprintf(stderr, "unknown command: %s\n%s", args[0], usage)
+	return 2
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func testdata(elem ...string) string {
+	return filepath.Join(append([]string{"parser", "testdata"}, elem...)...)
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestRun(t *testing.T) {
+	mbank := testdata("mt940", "input", "mbank.sta")
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		code   int
+		stdout []string
+		stderr string
+	}{
+		{name: "no command", code: 2, stderr: "usage: mt9x"},
+		{name: "help", args: []string{"help"}, stdout: []string{"usage: mt9x"}},
+		{name: "unknown command", args: []string{"print"}, code: 2, stderr: "unknown command: print"},
+		{
+			name:   "parse json",
+			args:   []string{"parse", "--validate", mbank},
+			stdout: []string{`"tag20": "ST170119CYC/1"`, `"tag25": {`},
+		},
+		{
+			name:   "parse yaml",
+			args:   []string{"parse", "--format", "yaml", mbank, mbank},
+			stdout: []string{"tag20: ST170119CYC/1\n", "\n---\n"},
+		},
+		{
+			name:   "parse stdin",
+			args:   []string{"parse", "--message-type", "auto"},
+			stdin:  readFile(t, testdata("mt941", "input", "minimal.sta")),
+			stdout: []string{`"tag20": "BR20240531"`, `"stmt_number": "00152"`},
+		},
+		{name: "parse bad format", args: []string{"parse", "--format", "xml", mbank}, code: 2, stderr: "unknown output format: xml"},
+		{name: "parse missing file", args: []string{"parse", testdata("missing.sta")}, code: 1, stderr: "no files match"},
+		{
+			name:   "parse error",
+			args:   []string{"parse", "--message-type", "941", mbank},
+			code:   1,
+			stderr: "mbank.sta: ",
+		},
+		{
+			name:   "validate",
+			args:   []string{"validate", testdata("mt940", "input", "m*.sta")},
+			stdout: []string{"mbank.sta: ok\n", "mbank2.sta: ok\n"},
+		},
+		{
+			name:   "validate many messages",
+			args:   []string{"validate"},
+			stdin:  readFile(t, mbank) + "\r\n" + readFile(t, mbank),
+			stdout: []string{"-#1: ok\n", "-#2: ok\n"},
+		},
+		{
+			name:   "validate json report",
+			args:   []string{"validate", "--report", "json", "--message-type", "941", testdata("mt941", "input", "minimal.sta")},
+			stdout: []string{`"status": "ok"`},
+		},
+		{
+			name:   "validate failure",
+			args:   []string{"validate", testdata("mt940", "input", "bnp.sta")},
+			code:   1,
+			stdout: []string{"bnp.sta: validation error: "},
+		},
+		{
+			name:   "convert csv",
+			args:   []string{"convert", "--to", "csv", mbank},
+			stdout: []string{"TransactionRefNo,RelatedReference,Account,", "\nST170119CYC/1,,PL29114010810000267002001002,"},
+		},
+		{
+			name:   "convert beancount",
+			args:   []string{"convert", "--to", "beancount", mbank},
+			stdout: []string{" balance Assets:Bank ", "  Equity:Uncategorized\n"},
+		},
+		{
+			name:   "convert own format",
+			args:   []string{"convert", "--message-type", "941", "--to", "mt941", testdata("mt941", "input", "minimal.sta")},
+			stdout: []string{":20:"},
+		},
+		{
+			name:   "convert unknown format",
+			args:   []string{"convert", "--message-type", "941", "--to", "csv", mbank},
+			code:   2,
+			stderr: `unknown output format "csv" for MT941, available: mt941`,
+		},
+		{
+			name:   "batch",
+			args:   []string{"batch", "--message-type", "941", "--validate", testdata("mt941", "input")},
+			stdout: []string{"minimal.sta: ok, 1 message(s)\n", "spec-example.sta: ok, 1 message(s)\n"},
+		},
+		{
+			name:   "batch json report",
+			args:   []string{"batch", "--report", "json", "--pattern", "mbank*.sta", testdata("mt940", "input")},
+			stdout: []string{`"message_count": 1`, `"status": "ok"`},
+		},
+		{
+			name:   "batch failure",
+			args:   []string{"batch", "--message-type", "941", testdata("mt940", "input")},
+			code:   1,
+			stdout: []string{"mbank.sta: parse error: "},
+		},
+		{name: "batch auto", args: []string{"batch", "--message-type", "auto", testdata("mt940", "input")}, code: 2, stderr: "batch requires message type number"},
+		{name: "batch no directory", args: []string{"batch"}, code: 2, stderr: "no directory given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			stderr := &strings.Builder{}
+			code := run(tt.args, strings.NewReader(tt.stdin), stdout, stderr)
+			assert.Equal(t, tt.code, code, stderr.String())
+			for _, s := range tt.stdout {
+				assert.Contains(t, stdout.String(), s)
+			}
+			assert.Contains(t, stderr.String(), tt.stderr)
+		})
+	}
+}
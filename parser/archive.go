@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// ArchiveResult is a message parsed from a single archive entry.
+type ArchiveResult[T MT9xMessage] struct {
+	// Name of the archive file.
+	Archive string
+	// Name of the entry in the archive, for gzip files it is the archive name without .gz extension.
+	Entry   string
+	Message *T
+	Err     error
+}
+
+// ArchiveEntry is a named content of the archive entry.
+type ArchiveEntry struct {
+	Name string
+	Data []byte
+	// Set for entries of zip archives.
+	Zipped bool
+}
+
+// Decompress returns decompressed data for gzip content or the data itself otherwise.
+func Decompress(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip data: %w", err)
+	}
+	defer r.Close()
+	result, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip data: %w", err)
+	}
+
+	return result, nil
+}
+
+// isZip checks if data is a zip archive.
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, zipMagic)
+}
+
+// ReadArchive returns all file entries of the zip archive, other data is returned
+// as a single entry. Gzip content is decompressed in both cases.
+func ReadArchive(name string, data []byte) ([]ArchiveEntry, error) {
+	if !isZip(data) {
+		content, err := Decompress(data)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(data, gzipMagic) {
+			name = strings.TrimSuffix(name, ".gz")
+		}
+		return []ArchiveEntry{{Name: name, Data: content}}, nil
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip archive: %w", err)
+	}
+	result := []ArchiveEntry{}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		content, err := fs.ReadFile(zr, f.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip entry %s: %w", f.Name, err)
+		}
+		content, err = Decompress(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip entry %s: %w", f.Name, err)
+		}
+		result = append(result, ArchiveEntry{Name: strings.TrimSuffix(f.Name, ".gz"), Data: content, Zipped: true})
+	}
+
+	return result, nil
+}
+
+// sourceName returns name used in error messages, zip entries are prefixed with archive name.
+func (e ArchiveEntry) sourceName(archive string) string {
+	if e.Zipped {
+		return archive + ":" + e.Name
+	}
+	return archive
+}
+
+// ParseArchive parses every entry of zip archive (or gzip/plain file as a single entry) from the file system.
+// Errors of the entries are reported in results, error is returned only if the archive cannot be read.
+func (fp *FileParser[T]) ParseArchive(fsys fs.FS, name string, validate bool, traceWriter io.Writer) ([]ArchiveResult[T], error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", name, err)
+	}
+	entries, err := ReadArchive(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive %s: %w", name, err)
+	}
+	result := make([]ArchiveResult[T], len(entries))
+	for i, e := range entries {
+		result[i] = ArchiveResult[T]{Archive: name, Entry: e.Name}
+		result[i].Message, result[i].Err = fp.parseData(e.sourceName(name), e.Data, validate, traceWriter)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+
+	"github.com/alecthomas/participle/v2"
+)
+
+type BatchStatus string
+
+const (
+	StatusOK              BatchStatus = "ok"
+	StatusParseError      BatchStatus = "parse error"
+	StatusValidationError BatchStatus = "validation error"
+	StatusCanceled        BatchStatus = "canceled"
+)
+
+// FileSummary describes result of processing single file in a batch.
+type FileSummary struct {
+	Path         string      `json:"path"`
+	Status       BatchStatus `json:"status"`
+	MessageCount int         `json:"message_count"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// BatchResult contains summary and messages parsed from single file.
+type BatchResult[T MT9xMessage] struct {
+	FileSummary
+	Messages []*T
+	Err      error
+}
+
+// BatchOptions configures batch processing.
+type BatchOptions struct {
+	// Number of concurrent workers, number of CPUs is used when not set.
+	Workers int
+	// Glob pattern matched against file base names, all files are processed when empty.
+	Pattern string
+	// Validate parsed messages.
+	Validate bool
+}
+
+type BatchParser[T MT9xMessage] struct {
+	parser    *participle.Parser[T]
+	tags      fieldTags
+	validator Validator
+	source    bool
+}
+
+// NewBatchParser creates parser processing many files concurrently.
+// Single parser instance is shared by all workers, as it is not modified during parsing.
+func NewBatchParser[T MT9xMessage]() *BatchParser[T] {
+	lexer := NewLexer()
+	parser := participle.MustBuild[T](
+		participle.Lexer(lexer),
+		participle.UseLookahead(2))
+	return &BatchParser[T]{
+		parser: parser,
+		tags:   newFieldTags[T](),
+	}
+}
+
+// WithValidator sets validator used when validation is requested, messages validate themselves when it is nil.
+// The validator is shared by all workers, so it must be safe for concurrent use.
+func (bp *BatchParser[T]) WithValidator(v Validator) *BatchParser[T] {
+	bp.validator = v
+	return bp
+}
+
+// WithSource keeps original text and positions of the parsed fields in Source of the messages,
+// positions refer to the archive entry or the file containing the message.
+func (bp *BatchParser[T]) WithSource() *BatchParser[T] {
+	bp.source = true
+	return bp
+}
+
+// ParseDir parses all files in the directory tree.
+func (bp *BatchParser[T]) ParseDir(ctx context.Context, dir string, opts BatchOptions) ([]BatchResult[T], error) {
+	return bp.ParseFS(ctx, os.DirFS(dir), opts)
+}
+
+// ParseFS parses all files in the file system tree with bounded number of workers.
+// Gzip files are decompressed and all entries of zip archives are parsed.
+// Results are ordered as files in the tree. When context is canceled, not processed files
+// have StatusCanceled and the context error is returned.
+func (bp *BatchParser[T]) ParseFS(ctx context.Context, fsys fs.FS, opts BatchOptions) ([]BatchResult[T], error) {
+	paths := []string{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if opts.Pattern != "" {
+			ok, err := path.Match(opts.Pattern, d.Name())
+			if err != nil {
+				return fmt.Errorf("bad file pattern %s: %w", opts.Pattern, err)
+			}
+			if !ok {
+				return nil
+			}
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk files: %w", err)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]BatchResult[T], len(paths))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = bp.parseFile(ctx, fsys, paths[i], opts.Validate)
+			}
+		}()
+	}
+	next := 0
+feed:
+	for ; next < len(paths); next++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- next:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	for i := next; i < len(paths); i++ {
+		results[i] = canceledResult[T](paths[i], ctx.Err())
+	}
+
+	return results, ctx.Err()
+}
+
+func canceledResult[T MT9xMessage](p string, err error) BatchResult[T] {
+	return BatchResult[T]{
+		FileSummary: FileSummary{Path: p, Status: StatusCanceled, Error: err.Error()},
+		Err:         err,
+	}
+}
+
+// parseFile parses and optionally validates all messages from the file.
+func (bp *BatchParser[T]) parseFile(ctx context.Context, fsys fs.FS, p string, validate bool) BatchResult[T] {
+	if err := ctx.Err(); err != nil {
+		return canceledResult[T](p, err)
+	}
+	result := BatchResult[T]{FileSummary: FileSummary{Path: p, Status: StatusOK}}
+	fail := func(status BatchStatus, err error) BatchResult[T] {
+		result.Status = status
+		result.Err = err
+		result.Error = err.Error()
+		return result
+	}
+	data, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return fail(StatusParseError, fmt.Errorf("failed to read file %s: %w", p, err))
+	}
+	entries, err := ReadArchive(p, data)
+	if err != nil {
+		return fail(StatusParseError, fmt.Errorf("failed to read file %s: %w", p, err))
+	}
+	for _, e := range entries {
+		name := e.sourceName(p)
+		// Messages are contiguous parts of the entry data.
+		start := 0
+		for _, msg := range SplitMessages(e.Data) {
+			_, text, offset, err := unwrapFIN(msg)
+			if err != nil {
+				return fail(StatusParseError, fmt.Errorf("failed to read file %s: %w", name, err))
+			}
+			offset += start
+			start += len(msg)
+			text, extensions, segments, err := bp.tags.extract(text, lineAt(e.Data, offset))
+			if err != nil {
+				return fail(StatusParseError, fmt.Errorf("failed to parse file %s: %w", name, err))
+			}
+			res, err := bp.parser.ParseBytes(name, text, participle.AllowTrailing(true))
+			if err != nil {
+				return fail(StatusParseError, fmt.Errorf("failed to parse file %s: %w", name, err))
+			}
+			setExtensions(res, extensions)
+			newSourceMap(e.Data, offset, segments).apply(res)
+			result.Messages = append(result.Messages, res)
+			result.MessageCount++
+		}
+	}
+	if result.MessageCount == 0 {
+		return fail(StatusParseError, fmt.Errorf("no messages found in file %s", p))
+	}
+	if validate {
+		for _, res := range result.Messages {
+			if err := validateMessage(bp.validator, *res); err != nil {
+				return fail(StatusValidationError, fmt.Errorf("failed to validate parsed result %s: %w", p, err))
+			}
+		}
+	}
+	if !bp.source {
+		for _, res := range result.Messages {
+			clearSources(res)
+		}
+	}
+
+	return result
+}
+
+// SplitMessages splits data containing many messages, every message starts with a line with field 20
+// or with FIN basic header (block 1) followed by the line with field 20.
+// Data before the first message is kept with it, so unexpected content is reported by the parser.
+func SplitMessages(data []byte) [][]byte {
+	result := [][]byte{}
+	start := 0
+	seen := false
+	seenRef := false
+	for i := 0; i < len(data); {
+		header := bytes.HasPrefix(data[i:], finBasicHeader)
+		ref := bytes.HasPrefix(data[i:], []byte(":20:"))
+		if header || (ref && seenRef) {
+			if seen {
+				result = append(result, data[start:i])
+				start = i
+			}
+			seenRef = false
+		}
+		if header || ref {
+			seen = true
+		}
+		if ref {
+			seenRef = true
+		}
+		next := bytes.IndexByte(data[i:], '\n')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	if len(bytes.TrimSpace(data[start:])) > 0 {
+		result = append(result, data[start:])
+	}
+
+	return result
+}
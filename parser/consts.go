@@ -23,5 +23,10 @@ const (
 	Alpha3                = AlphaUpper + AlphaUpper + AlphaUpper
 	Amount                = Numeric + `+(,` + Numeric + `*)?` // this is MT9x amount with comma instead a dot
 	TrxIdentCode          = `(?:S` + Numeric + Numeric + Numeric + `|[NF]` + AlphaNum + AlphaNum + AlphaNum + `)`
+	DateTimeInd           = Numeric + `{10}[+-]` + Numeric + `{4}` // YYMMDDhhmm followed by UTC offset
+	EntryCount            = Numeric + `{1,5}`
+	MessageTypeNo         = Numeric + `{3}`
+	PartyIdent            = `/` + CharX + `+`                                             // [/1!a][/34x] party identifier line
+	BICCode               = AlphaUpper + `{6}` + AlphaNum + `{2}(?:` + AlphaNum + `{3})?` // 4!a2!a2!c[3!c]
 	CRLF                  = "\r\n"
 )
@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/participle/v2"
+)
+
+// Extension is a non-standard field of the message, e.g. :NS: or local :99: field.
+type Extension struct {
+	Tag string `json:"tag"`
+	// Field content lines, the first one without the tag.
+	Lines []string `json:"lines"`
+	// Number of standard fields preceding the extension, it is used to restore the field position on serialization.
+	Position int `json:"position"`
+	// Line number of the field in the message text.
+	Line int `json:"line"`
+	// Value returned by the handler registered for the tag.
+	Value any `json:"value,omitempty"`
+}
+
+// Extensible keeps non-standard fields of the message, it is embedded in message structures.
+// Parsers of extensible messages do not fail on unknown fields, they are collected in Extensions instead.
+type Extensible struct {
+	Extensions []Extension `parser:"" json:"extensions,omitempty"`
+}
+
+// SetExtensions sets non-standard fields of the message.
+func (e *Extensible) SetExtensions(extensions []Extension) {
+	e.Extensions = extensions
+}
+
+type extensible interface {
+	SetExtensions(extensions []Extension)
+}
+
+// ExtensionHandler converts content lines of the non-standard field to a typed value.
+type ExtensionHandler func(tag string, lines []string) (any, error)
+
+var (
+	extensionHandlers   = map[string]ExtensionHandler{}
+	extensionHandlersMu sync.RWMutex
+)
+
+// RegisterExtensionHandler registers handler of the non-standard field, nil handler removes the registration.
+func RegisterExtensionHandler(tag string, handler ExtensionHandler) {
+	extensionHandlersMu.Lock()
+	defer extensionHandlersMu.Unlock()
+	if handler == nil {
+		delete(extensionHandlers, tag)
+		return
+	}
+	extensionHandlers[tag] = handler
+}
+
+func extensionHandler(tag string) ExtensionHandler {
+	extensionHandlersMu.RLock()
+	defer extensionHandlersMu.RUnlock()
+	return extensionHandlers[tag]
+}
+
+var (
+	fieldStart = regexp.MustCompile(`^:([0-9A-Z]{2,4}):`)
+	tokenName  = regexp.MustCompile(`\bT[0-9]{2}[A-Z]?\b`)
+	// Matches captures in parser tags, e.g. "@(T50A|T50)" or "@T60M".
+	capturedTokens = regexp.MustCompile(`@(\([^)]*\)|\w+)`)
+)
+
+// fieldTags contains tags of the standard fields of the message grammar.
+type fieldTags struct {
+	// Set when the message keeps non-standard fields.
+	enabled bool
+	// Matches field tag with colons, e.g. ":52A:", built from the lexer rule patterns.
+	standard *regexp.Regexp
+}
+
+var captureType = reflect.TypeFor[participle.Capture]()
+
+// newFieldTags collects tags from the lexer rules of tokens used in the message grammar.
+func newFieldTags[T MT9xMessage]() fieldTags {
+	ft := fieldTags{}
+	if _, ok := any(new(T)).(extensible); !ok {
+		return ft
+	}
+	ft.enabled = true
+	used := map[string]bool{}
+	collectTokens(reflect.TypeFor[T](), used, map[reflect.Type]bool{})
+	patterns := []string{}
+	for _, rule := range NewLexer().Rules()["Root"] {
+		if used[rule.Name] {
+			patterns = append(patterns, rule.Pattern)
+		}
+	}
+	ft.standard = regexp.MustCompile(`^(?:` + strings.Join(patterns, "|") + `)$`)
+
+	return ft
+}
+
+// collectTokens collects names of field tokens used in parser tags of the type.
+// Field tokens captured by custom types, e.g. the party option taken from any party field tag,
+// are skipped, as the enclosing grammar refers to the tags of its fields.
+func collectTokens(t reflect.Type, used map[string]bool, visited map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("parser")
+		if reflect.PointerTo(f.Type).Implements(captureType) {
+			tag = capturedTokens.ReplaceAllString(tag, "")
+		}
+		for _, name := range tokenName.FindAllString(tag, -1) {
+			used[name] = true
+		}
+		collectTokens(f.Type, used, visited)
+	}
+}
+
+// isStandard checks if the tag is a tag of the message grammar field.
+func (ft fieldTags) isStandard(tag string) bool {
+	return ft.standard.MatchString(":" + tag + ":")
+}
+
+// extract removes non-standard fields from the message text and returns them as extensions,
+// with segments of the text which were kept. Extension lines are counted from firstLine.
+func (ft fieldTags) extract(data []byte, firstLine int) ([]byte, []Extension, []segment, error) {
+	unchanged := []segment{{}}
+	if !ft.enabled {
+		return data, nil, unchanged, nil
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	result := make([]byte, 0, len(data))
+	extensions := []Extension{}
+	segments := []segment{}
+	current := -1
+	position := 0
+	offset := 0
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		lineOffset := offset
+		offset += len(line)
+		if m := fieldStart.FindSubmatch(line); m != nil {
+			current = -1
+			tag := string(m[1])
+			if ft.isStandard(tag) {
+				position++
+			} else {
+				extensions = append(extensions, Extension{Tag: tag, Position: position, Line: firstLine + i})
+				current = len(extensions) - 1
+				line = line[len(m[0]):]
+			}
+		}
+		if current < 0 {
+			if n := len(segments); n == 0 || segments[n-1].original+len(result)-segments[n-1].parsed != lineOffset {
+				segments = append(segments, segment{parsed: len(result), original: lineOffset})
+			}
+			result = append(result, line...)
+			continue
+		}
+		extensions[current].Lines = append(extensions[current].Lines, strings.TrimRight(string(line), "\r\n"))
+	}
+	if len(extensions) == 0 {
+		return data, nil, unchanged, nil
+	}
+	for i, e := range extensions {
+		if handler := extensionHandler(e.Tag); handler != nil {
+			value, err := handler(e.Tag, e.Lines)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("bad extension field %s in line %d: %w", e.Tag, e.Line, err)
+			}
+			extensions[i].Value = value
+		}
+	}
+
+	if len(segments) == 0 {
+		segments = unchanged
+	}
+
+	return result, extensions, segments, nil
+}
+
+// setExtensions sets extensions of the parsed message.
+func setExtensions[T MT9xMessage](res *T, extensions []Extension) {
+	if e, ok := any(res).(extensible); ok && len(extensions) > 0 {
+		e.SetExtensions(extensions)
+	}
+}
+
+// InsertExtensions inserts non-standard fields into the message text (with CRLF line endings)
+// at their original positions.
+func InsertExtensions(text string, extensions []Extension) string {
+	if len(extensions) == 0 {
+		return text
+	}
+	lines := strings.SplitAfter(text, CRLF)
+	result := strings.Builder{}
+	next := 0
+	insert := func(position int) {
+		for ; next < len(extensions) && extensions[next].Position <= position; next++ {
+			e := extensions[next]
+			result.WriteString(":" + e.Tag + ":" + strings.Join(e.Lines, CRLF) + CRLF)
+		}
+	}
+	position := 0
+	for _, line := range lines {
+		if fieldStart.MatchString(line) {
+			insert(position)
+			position++
+		}
+		result.WriteString(line)
+	}
+	insert(math.MaxInt)
+
+	return result.String()
+}
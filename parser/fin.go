@@ -0,0 +1,46 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+var (
+	finBasicHeader = []byte("{1:")
+	finAppHeader   = regexp.MustCompile(`\{2:[IO]([0-9]{3})`)
+	finTextStart   = []byte("{4:")
+	finTextEnd     = regexp.MustCompile(`(?:\r?\n)?-\}`)
+)
+
+// UnwrapFIN returns message type from the application header (block 2) and message text (block 4)
+// of the message in FIN format. Data without FIN basic header (block 1) is returned as it is,
+// with empty message type.
+func UnwrapFIN(data []byte) (string, []byte, error) {
+	messageType, text, _, err := unwrapFIN(data)
+	return messageType, text, err
+}
+
+// unwrapFIN works as UnwrapFIN and returns also offset of the message text in data.
+func unwrapFIN(data []byte) (string, []byte, int, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if !bytes.HasPrefix(trimmed, finBasicHeader) {
+		return "", data, 0, nil
+	}
+	start := bytes.Index(trimmed, finTextStart)
+	if start < 0 {
+		return "", nil, 0, fmt.Errorf("missing text block in FIN message")
+	}
+	messageType := ""
+	if m := finAppHeader.FindSubmatch(trimmed[:start]); m != nil {
+		messageType = string(m[1])
+	}
+	text := trimmed[start+len(finTextStart):]
+	text = bytes.TrimPrefix(bytes.TrimPrefix(text, []byte("\n")), []byte(CRLF))
+	end := finTextEnd.FindIndex(text)
+	if end == nil {
+		return "", nil, 0, fmt.Errorf("missing end of text block in FIN message")
+	}
+
+	return messageType, text[:end[0]], len(data) - len(text), nil
+}
@@ -13,14 +13,28 @@ func NewLexer() *lexer.StatefulDefinition {
 			{Name: "T25", Pattern: ":25:", Action: lexer.Push("OnlyChars")},
 			{Name: "T25P", Pattern: ":25P:", Action: lexer.Push("OnlyChars")},
 			{Name: "T28C", Pattern: ":28C:", Action: lexer.Push("StmtNumber")},
+			{Name: "T28", Pattern: ":28:", Action: lexer.Push("StmtNumber")},
+			{Name: "T13D", Pattern: ":13D:", Action: lexer.Push("DateTime")},
 			{Name: "T60F", Pattern: ":60F:", Action: lexer.Push("Balance_1")},
 			{Name: "T60M", Pattern: ":60M:", Action: lexer.Push("Balance_1")},
 			{Name: "T62F", Pattern: ":62F:", Action: lexer.Push("Balance_1")},
 			{Name: "T62M", Pattern: ":62M:", Action: lexer.Push("Balance_1")},
 			{Name: "T64", Pattern: ":64:", Action: lexer.Push("Balance_1")},
 			{Name: "T65", Pattern: ":65:", Action: lexer.Push("Balance_1")},
+			{Name: "T90D", Pattern: ":90D:", Action: lexer.Push("Entries")},
+			{Name: "T90C", Pattern: ":90C:", Action: lexer.Push("Entries")},
 			{Name: "T61", Pattern: ":61:", Action: lexer.Push("Statement_1")},
 			{Name: "T86", Pattern: ":86:", Action: nil},
+			{Name: "T32A", Pattern: ":32A:", Action: lexer.Push("Balance_1")},
+			{Name: "T50A", Pattern: ":50A:", Action: lexer.Push("PartyIdentifierCode")},
+			{Name: "T50", Pattern: ":50[DFK]:", Action: lexer.Push("PartyNameAddress")},
+			{Name: "T52A", Pattern: ":52A:", Action: lexer.Push("PartyIdentifierCode")},
+			{Name: "T52", Pattern: ":52[DFK]:", Action: lexer.Push("PartyNameAddress")},
+			{Name: "T56A", Pattern: ":56A:", Action: lexer.Push("PartyIdentifierCode")},
+			{Name: "T56", Pattern: ":56[DFK]:", Action: lexer.Push("PartyNameAddress")},
+			{Name: "T72", Pattern: ":72:", Action: nil},
+			{Name: "T12", Pattern: ":12:", Action: lexer.Push("MessageType")},
+			{Name: "T34F", Pattern: ":34F:", Action: lexer.Push("FloorLimit")},
 			{Name: "CRLF", Pattern: CRLF, Action: nil},
 			{Name: "CharXSeq", Pattern: CharXSeq, Action: nil},
 		},
@@ -39,6 +53,36 @@ func NewLexer() *lexer.StatefulDefinition {
 			{Name: "NumSeq", Pattern: NumSeq, Action: nil},
 			lexer.Return(),
 		},
+		"DateTime": {
+			{Name: "DateTimeInd", Pattern: DateTimeInd, Action: nil},
+			lexer.Return(),
+		},
+		"Entries": {
+			{Name: "EntryCount", Pattern: EntryCount, Action: lexer.Push("Balance_2")},
+			lexer.Return(),
+		},
+		"MessageType": {
+			{Name: "MsgType", Pattern: MessageTypeNo, Action: nil},
+			lexer.Return(),
+		},
+		"FloorLimit": {
+			{Name: "Currency", Pattern: Alpha3, Action: nil},
+			{Name: "DCMark", Pattern: DCMark, Action: nil},
+			{Name: "Amount", Pattern: Amount, Action: nil},
+			lexer.Return(),
+		},
+		"PartyIdentifierCode": {
+			{Name: "PartyId", Pattern: PartyIdent, Action: nil},
+			{Name: "CRLF", Pattern: CRLF, Action: nil},
+			{Name: "BIC", Pattern: BICCode, Action: nil},
+			lexer.Return(),
+		},
+		"PartyNameAddress": {
+			{Name: "PartyId", Pattern: PartyIdent, Action: nil},
+			{Name: "CRLF", Pattern: CRLF, Action: nil},
+			{Name: "CharXSeq", Pattern: CharXSeq, Action: nil},
+			lexer.Return(),
+		},
 		"Balance_1": []lexer.Rule{
 			{Name: "DCMark", Pattern: DCMark, Action: nil},
 			{Name: "Date", Pattern: Numeric46, Action: lexer.Push("Balance_2")},
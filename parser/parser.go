@@ -3,13 +3,17 @@ package parser
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 
 	"github.com/alecthomas/participle/v2"
 )
 
 type FileParser[T MT9xMessage] struct {
-	parser *participle.Parser[T]
+	parser    *participle.Parser[T]
+	tags      fieldTags
+	validator Validator
+	source    bool
 }
 
 // NewFileParser creates new file parser for MT940 messages.
@@ -20,34 +24,81 @@ func NewFileParser[T MT9xMessage]() *FileParser[T] {
 		participle.UseLookahead(2))
 	return &FileParser[T]{
 		parser: parser,
+		tags:   newFieldTags[T](),
 	}
 }
 
-// Parse parses MT940 message into structure.
+// WithValidator sets validator used when validation is requested, messages validate themselves when it is nil.
+func (fp *FileParser[T]) WithValidator(v Validator) *FileParser[T] {
+	fp.validator = v
+	return fp
+}
+
+// WithSource keeps original text and positions of the parsed fields in Source of the message.
+func (fp *FileParser[T]) WithSource() *FileParser[T] {
+	fp.source = true
+	return fp
+}
+
+// Parse parses MT940 message into structure, gzip compressed file is decompressed
+// and message in FIN format is unwrapped.
 func (fp *FileParser[T]) Parse(filename string, validate bool, traceWriter io.Writer) (*T, error) {
-	f, err := os.Open(filename)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	return fp.parseData(filename, data, validate, traceWriter)
+}
+
+// ParseFS parses MT940 message from the file system, gzip compressed file is decompressed.
+func (fp *FileParser[T]) ParseFS(fsys fs.FS, filename string, validate bool, traceWriter io.Writer) (*T, error) {
+	data, err := fs.ReadFile(fsys, filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file %s: %w", filename, err)
 	}
+	return fp.parseData(filename, data, validate, traceWriter)
+}
+
+func (fp *FileParser[T]) parseData(filename string, data []byte, validate bool, traceWriter io.Writer) (*T, error) {
+	data, err := Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	_, text, offset, err := unwrapFIN(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	text, extensions, segments, err := fp.tags.extract(text, lineAt(data, offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %s: %w", filename, err)
+	}
 	options := []participle.ParseOption{participle.AllowTrailing(true)}
 	if traceWriter != nil {
 		options = append(options, participle.Trace(traceWriter))
 	}
-	res, err := fp.parser.Parse(filename, f, options...)
+	res, err := fp.parser.ParseBytes(filename, text, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse file %s: %w", filename, err)
 	}
+	setExtensions(res, extensions)
+	newSourceMap(data, offset, segments).apply(res)
 	if validate {
-		if err = (*res).Validate(); err != nil {
+		if err = validateMessage(fp.validator, *res); err != nil {
 			return nil, fmt.Errorf("failed to validate parsed result %s: %w", filename, err)
 		}
 	}
+	if !fp.source {
+		clearSources(res)
+	}
 
 	return res, nil
 }
 
 type ByteParser[T MT9xMessage] struct {
-	parser *participle.Parser[T]
+	parser    *participle.Parser[T]
+	tags      fieldTags
+	validator Validator
+	source    bool
 }
 
 // NewByteParser creates new byte parser for MT940 messages.
@@ -58,24 +109,55 @@ func NewByteParser[T MT9xMessage]() *ByteParser[T] {
 		participle.UseLookahead(2))
 	return &ByteParser[T]{
 		parser: parser,
+		tags:   newFieldTags[T](),
 	}
 }
 
-// Parse parses MT940 message (from data) into structure.
+// WithValidator sets validator used when validation is requested, messages validate themselves when it is nil.
+func (fp *ByteParser[T]) WithValidator(v Validator) *ByteParser[T] {
+	fp.validator = v
+	return fp
+}
+
+// WithSource keeps original text and positions of the parsed fields in Source of the message.
+func (fp *ByteParser[T]) WithSource() *ByteParser[T] {
+	fp.source = true
+	return fp
+}
+
+// Parse parses MT940 message (from data) into structure, gzip compressed data is decompressed
+// and message in FIN format is unwrapped.
 func (fp *ByteParser[T]) Parse(data []byte, validate bool, traceWriter io.Writer) (*T, error) {
+	data, err := Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bytes: %w", err)
+	}
+	_, text, offset, err := unwrapFIN(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bytes: %w", err)
+	}
+	text, extensions, segments, err := fp.tags.extract(text, lineAt(data, offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bytes: %w", err)
+	}
 	options := []participle.ParseOption{participle.AllowTrailing(true)}
 	if traceWriter != nil {
 		options = append(options, participle.Trace(traceWriter))
 	}
-	res, err := fp.parser.ParseBytes("byte data", data, options...)
+	res, err := fp.parser.ParseBytes("byte data", text, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse bytes: %w", err)
 	}
+	setExtensions(res, extensions)
+	newSourceMap(data, offset, segments).apply(res)
 	if validate {
-		if err = (*res).Validate(); err != nil {
+		if err = validateMessage(fp.validator, *res); err != nil {
 			return nil, fmt.Errorf("failed to validate parsed result: %w", err)
 		}
 	}
+	if !fp.source {
+		clearSources(res)
+	}
 
 	return res, nil
 }
@@ -1,11 +1,18 @@
 package parser_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/alecthomas/assert/v2"
 	"github.com/oswida/mt9x/grammar"
@@ -13,9 +20,10 @@ import (
 	"gotest.tools/v3/golden"
 )
 
-func TestProperMT940Files(t *testing.T) {
-	parser := parser.NewFileParser[grammar.MT940Message]()
-	basePath := filepath.Join("testdata", "mt940")
+func testProperFiles[T parser.MT9xMessage](t *testing.T, messageType string) {
+	t.Helper()
+	parser := parser.NewFileParser[T]()
+	basePath := filepath.Join("testdata", messageType)
 	files, err := os.ReadDir(filepath.Join(basePath, "input"))
 	assert.NoError(t, err)
 	for _, f := range files {
@@ -26,7 +34,307 @@ func TestProperMT940Files(t *testing.T) {
 		assert.NoError(t, err)
 		value, err := json.MarshalIndent(result, "", " ")
 		assert.NoError(t, err)
-		golden.Assert(t, string(value), filepath.Join("mt940", "expected", strings.ReplaceAll(f.Name(), ".sta", ".json")))
+		golden.Assert(t, string(value), filepath.Join(messageType, "expected", strings.ReplaceAll(f.Name(), ".sta", ".json")))
 	}
+}
+
+func TestProperMT940Files(t *testing.T) {
+	testProperFiles[grammar.MT940Message](t, "mt940")
+}
+
+func TestProperMT942Files(t *testing.T) {
+	testProperFiles[grammar.MT942Message](t, "mt942")
+}
+
+func TestProperMT950Files(t *testing.T) {
+	testProperFiles[grammar.MT950Message](t, "mt950")
+}
+
+func TestProperMT941Files(t *testing.T) {
+	testProperFiles[grammar.MT941Message](t, "mt941")
+}
+
+func TestProperMT900Files(t *testing.T) {
+	testProperFiles[grammar.MT900Message](t, "mt900")
+}
+
+func TestProperMT910Files(t *testing.T) {
+	testProperFiles[grammar.MT910Message](t, "mt910")
+}
+
+func TestProperMT920Files(t *testing.T) {
+	testProperFiles[grammar.MT920Message](t, "mt920")
+}
+
+func TestProperMT970Files(t *testing.T) {
+	testProperFiles[grammar.MT970Message](t, "mt970")
+}
+
+func TestProperMT971Files(t *testing.T) {
+	testProperFiles[grammar.MT971Message](t, "mt971")
+}
+
+func TestProperMT972Files(t *testing.T) {
+	testProperFiles[grammar.MT972Message](t, "mt972")
+}
+
+func TestProperMT973Files(t *testing.T) {
+	testProperFiles[grammar.MT973Message](t, "mt973")
+}
 
+func TestBatchParseFS(t *testing.T) {
+	bp := parser.NewBatchParser[grammar.MT940Message]()
+	input := filepath.Join("testdata", "mt940", "input")
+	first, err := os.ReadFile(filepath.Join(input, "csob.sta"))
+	assert.NoError(t, err)
+	second, err := os.ReadFile(filepath.Join(input, "mbank.sta"))
+	assert.NoError(t, err)
+	fsys := fstest.MapFS{
+		"a/two.sta":     {Data: append(append(first, "\r\n"...), second...)},
+		"a/two.fin.sta": {Data: []byte(finWrapped(first) + "\r\n" + finWrapped(second))},
+		"b/broken.sta":  {Data: []byte(":20:X\r\n:25:1\r\n")},
+		"b/empty.sta":   {Data: []byte{}},
+		"c/ignored.txt": {Data: []byte("x")},
+	}
+	results, err := bp.ParseFS(context.Background(), fsys, parser.BatchOptions{Workers: 2, Pattern: "*.sta"})
+	assert.NoError(t, err)
+	summaries := []parser.FileSummary{}
+	for _, r := range results {
+		summaries = append(summaries, parser.FileSummary{Path: r.Path, Status: r.Status, MessageCount: r.MessageCount})
+	}
+	assert.Equal(t, []parser.FileSummary{
+		{Path: "a/two.fin.sta", Status: parser.StatusOK, MessageCount: 2},
+		{Path: "a/two.sta", Status: parser.StatusOK, MessageCount: 2},
+		{Path: "b/broken.sta", Status: parser.StatusParseError},
+		{Path: "b/empty.sta", Status: parser.StatusParseError},
+	}, summaries)
+	assert.Equal(t, "PL29114010810000267002001002", results[0].Messages[1].AccountIdentification.Account)
+	fin, err := json.Marshal(results[0].Messages)
+	assert.NoError(t, err)
+	plain, err := json.Marshal(results[1].Messages)
+	assert.NoError(t, err)
+	assert.Equal(t, string(plain), string(fin))
+
+	results, err = bp.ParseDir(context.Background(), input, parser.BatchOptions{Workers: 4})
+	assert.NoError(t, err)
+	files, err := os.ReadDir(input)
+	assert.NoError(t, err)
+	assert.Equal(t, len(files), len(results))
+	for _, r := range results {
+		assert.Equal(t, parser.StatusOK, r.Status, r.Path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = bp.ParseDir(ctx, input, parser.BatchOptions{})
+	assert.IsError(t, err, context.Canceled)
+	for _, r := range results {
+		assert.Equal(t, parser.StatusCanceled, r.Status, r.Path)
+	}
+}
+
+func finWrapped(text []byte) string {
+	return "{1:F01BANKBEBBAXXX0000000000}{2:O9401200240603BANKDEFFXXXX00000000002406031200N}{4:\r\n" +
+		strings.TrimRight(string(text), "\r\n") + "\r\n-}"
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseArchives(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	input := filepath.Join("testdata", "mt940", "input")
+	csob, err := os.ReadFile(filepath.Join(input, "csob.sta"))
+	assert.NoError(t, err)
+	mbank, err := os.ReadFile(filepath.Join(input, "mbank.sta"))
+	assert.NoError(t, err)
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, data := range map[string][]byte{"csob.sta": csob, "mbank.sta.gz": gzipped(t, mbank), "bad.sta": []byte("x")} {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+	}
+	_, err = zw.Create("dir/")
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	fsys := fstest.MapFS{
+		"daily.zip":   {Data: buf.Bytes()},
+		"csob.sta.gz": {Data: gzipped(t, csob)},
+	}
+
+	m, err := fp.ParseFS(fsys, "csob.sta.gz", false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "31MAR17DAILY", m.TransactionRefNo)
+
+	results, err := fp.ParseArchive(fsys, "csob.sta.gz", false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, "csob.sta", results[0].Entry)
+	assert.NoError(t, results[0].Err)
+
+	results, err = fp.ParseArchive(fsys, "daily.zip", false, nil)
+	assert.NoError(t, err)
+	entries := map[string]parser.ArchiveResult[grammar.MT940Message]{}
+	for _, r := range results {
+		assert.Equal(t, "daily.zip", r.Archive)
+		entries[r.Entry] = r
+	}
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, "31MAR17DAILY", entries["csob.sta"].Message.TransactionRefNo)
+	assert.Equal(t, "ST170119CYC/1", entries["mbank.sta"].Message.TransactionRefNo)
+	assert.Error(t, entries["bad.sta"].Err)
+
+	bp := parser.NewBatchParser[grammar.MT940Message]()
+	batch, err := bp.ParseFS(context.Background(), fsys, parser.BatchOptions{Pattern: "*.gz"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(batch))
+	assert.Equal(t, 1, batch[0].MessageCount)
+}
+
+func TestExtensions(t *testing.T) {
+	parser.RegisterExtensionHandler("NS", func(tag string, lines []string) (any, error) {
+		result := map[string]string{}
+		for _, l := range lines {
+			result[l[:2]] = l[2:]
+		}
+		return result, nil
+	})
+	t.Cleanup(func() { parser.RegisterExtensionHandler("NS", nil) })
+
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	m, err := fp.Parse(filepath.Join("testdata", "mt940", "input", "extensions.sta"), true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []parser.Extension{
+		{Tag: "NS", Lines: []string{"22BANK STATEMENT", "23PRIVATE LINE"}, Position: 3, Line: 4,
+			Value: map[string]string{"22": "BANK STATEMENT", "23": "PRIVATE LINE"}},
+		{Tag: "13", Lines: []string{"2406031200"}, Position: 4, Line: 7},
+		{Tag: "99", Lines: []string{"LOCAL"}, Position: 6, Line: 11},
+		{Tag: "13D", Lines: []string{"2406031730+0200"}, Position: 7, Line: 13},
+	}, m.Extensions)
+
+	parser.RegisterExtensionHandler("NS", func(tag string, lines []string) (any, error) {
+		return nil, fmt.Errorf("unexpected content")
+	})
+	_, err = fp.Parse(filepath.Join("testdata", "mt940", "input", "extensions.sta"), true, nil)
+	assert.EqualError(t, err, "failed to parse file testdata/mt940/input/extensions.sta: bad extension field NS in line 4: unexpected content")
+
+	// Party fields not defined for MT900 are kept as extensions.
+	mt900, err := parser.NewByteParser[grammar.MT900Message]().Parse([]byte(
+		":20:C11126A1378\r\n:21:5482ABC\r\n:25:9-9876543\r\n:32A:090123USD233530,\r\n:52A:BKAUATWW\r\n:56A:BKTRUS33\r\n"), true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "A", string(mt900.OrderingInstitution.Option))
+	assert.Equal(t, []parser.Extension{{Tag: "56A", Lines: []string{"BKTRUS33"}, Position: 5, Line: 6}}, mt900.Extensions)
+}
+
+func TestFieldSource(t *testing.T) {
+	filename := filepath.Join("testdata", "mt940", "input", "csob.sta")
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	m, err := parser.NewFileParser[grammar.MT940Message]().Parse(filename, false, nil)
+	assert.NoError(t, err)
+	assert.Zero(t, m.Source)
+	assert.Zero(t, m.OpeningBalance.Source)
+
+	m, err = parser.NewFileParser[grammar.MT940Message]().WithSource().Parse(filename, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), m.Raw())
+	assert.Equal(t, "C170330CZK100,00", m.OpeningBalance.Raw())
+	assert.Equal(t, 4, m.OpeningBalance.Pos.Line)
+	assert.Equal(t, 6, m.OpeningBalance.Pos.Column)
+	assert.Equal(t, "C170330CZK100,00", string(data[m.OpeningBalance.Pos.Offset:m.OpeningBalance.EndPos.Offset]))
+	section := m.Statements[0]
+	assert.Equal(t, "1703310331D1,20NMSC12345678909876//3150636703\r\n/OCMT/CZK1,20", section.Statement.Raw())
+	assert.True(t, strings.HasPrefix(section.Raw(), ":61:"+section.Statement.Raw()+"\r\n:86:030?00Kurs"))
+	assert.Equal(t, section.Pos, section.Tokens[0].Pos)
+	assert.Equal(t, "0000000123456", m.AccountIdentification.Raw())
+	assert.Equal(t, "00065/1", m.StatementNumber.Raw())
+	fields := m.Fields()
+	assert.Equal(t, "20", fields[0].Tag)
+	assert.Equal(t, ":20:31MAR17DAILY\r\n", fields[0].Raw())
+	assert.Equal(t, 1, fields[0].Pos.Line)
+	assert.Equal(t, fields[1].Pos, fields[0].EndPos)
+}
+
+func TestFieldSourcePositions(t *testing.T) {
+	// Positions include FIN header lines.
+	filename := filepath.Join("testdata", "mt950", "input", "fin-envelope.sta")
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	mt950, err := parser.NewFileParser[grammar.MT950Message]().WithSource().Parse(filename, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mt950.Pos.Line)
+	line := mt950.Statements[5].Statement
+	assert.Equal(t, 11, line.Pos.Line)
+	assert.Equal(t, 5, line.Pos.Column)
+	assert.Equal(t, line.Raw(), string(data[line.Pos.Offset:line.EndPos.Offset]))
+
+	// Positions include lines of non-standard fields removed before parsing.
+	filename = filepath.Join("testdata", "mt940", "input", "extensions.sta")
+	data, err = os.ReadFile(filename)
+	assert.NoError(t, err)
+	mt940, err := parser.NewFileParser[grammar.MT940Message]().WithSource().Parse(filename, false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, mt940.OpeningBalance.Pos.Line)
+	assert.Equal(t, "C240602PLN1000,00", string(data[mt940.OpeningBalance.Pos.Offset:mt940.OpeningBalance.EndPos.Offset]))
+	assert.Equal(t, 12, mt940.ClosingBalance.Pos.Line)
+	assert.Equal(t, 8, mt940.Statements[0].Pos.Line)
+	// Section ends before the removed field 99.
+	assert.Equal(t, "FOR INVOICE", string(data[mt940.Statements[0].EndPos.Offset-len("FOR INVOICE\r\n"):mt940.Statements[0].EndPos.Offset-2]))
+	assert.NotContains(t, mt940.Raw(), ":NS:")
+
+	// Positions in batch refer to the file containing many messages.
+	two := []byte(finWrapped(data) + "\r\n" + finWrapped(data))
+	bp := parser.NewBatchParser[grammar.MT940Message]().WithSource()
+	results, err := bp.ParseFS(context.Background(), fstest.MapFS{"two.sta": {Data: two}}, parser.BatchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, results[0].MessageCount)
+	balance := results[0].Messages[1].OpeningBalance
+	assert.Equal(t, 22, balance.Pos.Line)
+	assert.Equal(t, "C240602PLN1000,00", string(two[balance.Pos.Offset:balance.EndPos.Offset]))
+}
+
+type currencies []string
+
+func (c currencies) IsValid(code string) bool {
+	return slices.Contains(c, code)
+}
+
+func TestValidator(t *testing.T) {
+	filename := filepath.Join("testdata", "mt940", "input", "csob.sta")
+	validator := grammar.NewValidator(grammar.WithCurrencyProvider(currencies{"EUR"}))
+	_, err := parser.NewFileParser[grammar.MT940Message]().WithValidator(validator).Parse(filename, true, nil)
+	assert.EqualError(t, err, "failed to validate parsed result "+filename+": bad opening balance: bad currency code: CZK")
+	_, err = parser.NewFileParser[grammar.MT940Message]().Parse(filename, true, nil)
+	assert.NoError(t, err)
+	_, err = parser.NewFileParser[grammar.MT940Message]().WithValidator(grammar.NewValidator(grammar.WithFormatChecks())).Parse(filename, true, nil)
+	assert.EqualError(t, err, "failed to validate parsed result "+filename+": field 86 in line 20: length 71 exceeds 65 characters")
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	_, err = parser.NewByteParser[grammar.MT940Message]().WithValidator(validator).Parse(data, true, nil)
+	assert.EqualError(t, err, "failed to validate parsed result: bad opening balance: bad currency code: CZK")
+
+	// Shared validator gives the same results as validation by messages themselves.
+	input := filepath.Join("testdata", "mt940", "input")
+	options := parser.BatchOptions{Workers: 4, Validate: true}
+	results, err := parser.NewBatchParser[grammar.MT940Message]().WithValidator(grammar.NewValidator()).ParseDir(context.Background(), input, options)
+	assert.NoError(t, err)
+	expected, err := parser.NewBatchParser[grammar.MT940Message]().ParseDir(context.Background(), input, options)
+	assert.NoError(t, err)
+	for i, r := range results {
+		assert.Equal(t, expected[i].FileSummary, r.FileSummary)
+	}
+	results, err = parser.NewBatchParser[grammar.MT940Message]().WithValidator(validator).
+		ParseFS(context.Background(), fstest.MapFS{"csob.sta": {Data: data}}, parser.BatchOptions{Validate: true})
+	assert.NoError(t, err)
+	assert.Equal(t, parser.StatusValidationError, results[0].Status)
 }
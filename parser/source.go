@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/participle/v2/lexer"
+)
+
+// Source keeps original text and position of the parsed field, it is filled by the parser when
+// it is created with WithSource option and is not a part of JSON representation. Positions refer
+// to the parsed data, i.e. the file, decompressed data or archive entry, including FIN envelope
+// and non-standard fields.
+type Source struct {
+	// Start position of the field.
+	Pos lexer.Position `parser:"" json:"-"`
+	// Position just after the field.
+	EndPos lexer.Position `parser:"" json:"-"`
+	// Tokens matched by the field.
+	Tokens []lexer.Token `parser:"" json:"-"`
+}
+
+// Raw returns original text of the field.
+func (s Source) Raw() string {
+	result := strings.Builder{}
+	for _, t := range s.Tokens {
+		result.WriteString(t.Value)
+	}
+	return result.String()
+}
+
+// FieldSource is the source of a single field, e.g. field 20 without its own structure.
+type FieldSource struct {
+	// Field tag without colons, e.g. "20" or "60F".
+	Tag string
+	Source
+}
+
+var tagToken = regexp.MustCompile(`^:([0-9]{2}[A-Z]?):$`)
+
+// Fields splits the source into fields starting with field tags, tokens before the first tag are skipped.
+func (s Source) Fields() []FieldSource {
+	result := []FieldSource{}
+	for i, t := range s.Tokens {
+		if m := tagToken.FindStringSubmatch(t.Value); m != nil {
+			result = append(result, FieldSource{Tag: m[1], Source: Source{Pos: t.Pos}})
+		}
+		if len(result) == 0 {
+			continue
+		}
+		field := &result[len(result)-1]
+		field.Tokens = s.Tokens[i-len(field.Tokens) : i+1]
+		field.EndPos = t.Pos
+		field.EndPos.Advance(t.Value)
+	}
+	return result
+}
+
+// segment is a part of the parsed text copied from the original data.
+type segment struct {
+	// Offset in the parsed text.
+	parsed int
+	// Offset in the original data.
+	original int
+}
+
+// sourceMap maps positions in the parsed text to positions in the original data,
+// which contains FIN envelope and non-standard fields removed before parsing.
+type sourceMap struct {
+	data       []byte
+	segments   []segment
+	lineStarts []int
+}
+
+// newSourceMap creates map of the text found at offset of data, segments are relative to the text.
+func newSourceMap(data []byte, offset int, segments []segment) sourceMap {
+	sm := sourceMap{data: data, lineStarts: []int{0}}
+	for _, s := range segments {
+		sm.segments = append(sm.segments, segment{parsed: s.parsed, original: s.original + offset})
+	}
+	for i, c := range data {
+		if c == '\n' {
+			sm.lineStarts = append(sm.lineStarts, i+1)
+		}
+	}
+	return sm
+}
+
+// lineAt returns line number of the offset in data.
+func lineAt(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// position maps position in the parsed text, the end position is placed after the preceding segment
+// when it is on the boundary of two segments.
+func (sm sourceMap) position(p lexer.Position, end bool) lexer.Position {
+	i := sort.Search(len(sm.segments), func(i int) bool {
+		if end {
+			return sm.segments[i].parsed >= p.Offset
+		}
+		return sm.segments[i].parsed > p.Offset
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	offset := sm.segments[i].original + p.Offset - sm.segments[i].parsed
+	line := sort.Search(len(sm.lineStarts), func(i int) bool { return sm.lineStarts[i] > offset })
+	return lexer.Position{
+		Filename: p.Filename,
+		Offset:   offset,
+		Line:     line,
+		Column:   utf8.RuneCount(sm.data[sm.lineStarts[line-1]:offset]) + 1,
+	}
+}
+
+// apply maps positions of all sources of the parsed message.
+func (sm sourceMap) apply(res any) {
+	walkSources(reflect.ValueOf(res), func(s *Source) {
+		if len(s.Tokens) == 0 {
+			return
+		}
+		s.Pos = sm.position(s.Pos, false)
+		s.EndPos = sm.position(s.EndPos, true)
+		// Token slices of nested fields share the array with the enclosing field.
+		s.Tokens = slices.Clone(s.Tokens)
+		for i, t := range s.Tokens {
+			s.Tokens[i].Pos = sm.position(t.Pos, false)
+		}
+	})
+}
+
+// clearSources removes sources of the parsed message.
+func clearSources(res any) {
+	walkSources(reflect.ValueOf(res), func(s *Source) {
+		*s = Source{}
+	})
+}
+
+var sourceType = reflect.TypeFor[Source]()
+
+// walkSources calls f for every source embedded in the value or its exported fields.
+func walkSources(v reflect.Value, f func(*Source)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			walkSources(v.Elem(), f)
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			walkSources(v.Index(i), f)
+		}
+	case reflect.Struct:
+		if v.Type() == sourceType {
+			f(v.Addr().Interface().(*Source))
+			return
+		}
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				walkSources(v.Field(i), f)
+			}
+		}
+	}
+}
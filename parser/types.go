@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +16,61 @@ type MT9xMessage interface {
 	Validate() error
 }
 
+// TypedMessage is implemented by messages knowing their SWIFT message type.
+type TypedMessage interface {
+	MT9xMessage
+	// MessageType returns SWIFT message type number, e.g. "940".
+	MessageType() string
+}
+
+// MessageType returns SWIFT message type number of the message, or empty string when it is not a TypedMessage.
+func MessageType(m MT9xMessage) string {
+	if tm, ok := m.(TypedMessage); ok {
+		return tm.MessageType()
+	}
+	return ""
+}
+
+// Validator validates messages, e.g. with reference data shared by many validations.
+type Validator interface {
+	Validate(m MT9xMessage) error
+}
+
+// validateMessage validates the message with the validator, or with its own Validate method if validator is nil.
+func validateMessage(v Validator, m MT9xMessage) error {
+	if v == nil {
+		return m.Validate()
+	}
+	return v.Validate(m)
+}
+
+const (
+	// JSONDateLayout is used for all dates in JSON representation.
+	JSONDateLayout = time.RFC3339
+)
+
+// unquoteJSON returns JSON string content or raw token for other JSON values.
+func unquoteJSON(data []byte) (string, error) {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// parseJSONDate parses date in JSON layout, ISO date only layout or MT format layout.
+func parseJSONDate(value string, mtLayout string) (time.Time, error) {
+	for _, layout := range []string{JSONDateLayout, time.DateOnly, mtLayout} {
+		if v, err := time.Parse(layout, value); err == nil {
+			return v, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad date: %s", value)
+}
+
 // CommaDecimal captures decimal with comma (instead of dot) as a decimal sign
 type CommaDecimal struct {
 	decimal.Decimal
@@ -31,6 +89,48 @@ func (d *CommaDecimal) Capture(values []string) error {
 	return nil
 }
 
+// MTString formats decimal in MT format, with a comma as a decimal sign which is always present.
+// Fractional digits are kept as captured.
+func (d CommaDecimal) MTString() string {
+	v := d.Decimal.String()
+	if d.Exponent() < 0 {
+		v = d.StringFixed(-d.Exponent())
+	}
+	v = strings.ReplaceAll(v, ".", ",")
+	if !strings.Contains(v, ",") {
+		v += ","
+	}
+	return v
+}
+
+// MarshalJSON serializes decimal as a JSON string with a dot as a decimal sign.
+func (d CommaDecimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Decimal.String())
+}
+
+// JSONSchema describes JSON representation of the decimal.
+func (CommaDecimal) JSONSchema() map[string]any {
+	return map[string]any{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?$`}
+}
+
+// UnmarshalJSON accepts JSON string or number, with a dot or a comma as a decimal sign.
+func (d *CommaDecimal) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for CommaDecimal: %w", err)
+	}
+	if s == "null" {
+		return nil
+	}
+	v, err := decimal.NewFromString(strings.ReplaceAll(s, ",", "."))
+	if err != nil {
+		return fmt.Errorf("bad JSON value for CommaDecimal: %w", err)
+	}
+
+	d.Decimal = v
+	return nil
+}
+
 // SixDigitDate captures dates in YYMMDD format.
 type SixDigitDate struct {
 	time.Time
@@ -49,6 +149,39 @@ func (d *SixDigitDate) Capture(values []string) error {
 	return nil
 }
 
+// MTString formats date in YYMMDD format.
+func (d SixDigitDate) MTString() string {
+	return d.Format("060102")
+}
+
+// MarshalJSON serializes date as RFC 3339 timestamp.
+func (d SixDigitDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(JSONDateLayout))
+}
+
+// JSONSchema describes JSON representation of the date.
+func (SixDigitDate) JSONSchema() map[string]any {
+	return map[string]any{"type": "string", "format": "date-time"}
+}
+
+// UnmarshalJSON accepts RFC 3339 timestamp, YYYY-MM-DD or YYMMDD date.
+func (d *SixDigitDate) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for SixDigitDate: %w", err)
+	}
+	if s == "null" {
+		return nil
+	}
+	v, err := parseJSONDate(s, "060102")
+	if err != nil {
+		return fmt.Errorf("bad JSON value for SixDigitDate: %w", err)
+	}
+
+	d.Time = v
+	return nil
+}
+
 // FourDigitDate captures dates in MMDD format.
 type FourDigitDate struct {
 	time.Time
@@ -66,3 +199,171 @@ func (d *FourDigitDate) Capture(values []string) error {
 	d.Time = v
 	return nil
 }
+
+// MTString formats date in MMDD format.
+func (d FourDigitDate) MTString() string {
+	return d.Format("0102")
+}
+
+// MarshalJSON serializes date as RFC 3339 timestamp, the year is always 0000 as MT format does not carry it.
+func (d FourDigitDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Date(0, d.Month(), d.Day(), 0, 0, 0, 0, time.UTC).Format(JSONDateLayout))
+}
+
+// JSONSchema describes JSON representation of the date.
+func (FourDigitDate) JSONSchema() map[string]any {
+	return map[string]any{"type": "string", "format": "date-time", "pattern": `^0000-`}
+}
+
+// UnmarshalJSON accepts RFC 3339 timestamp, YYYY-MM-DD or MMDD date, the year is ignored.
+func (d *FourDigitDate) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for FourDigitDate: %w", err)
+	}
+	if s == "null" {
+		return nil
+	}
+	v, err := parseJSONDate(s, "0102")
+	if err != nil {
+		return fmt.Errorf("bad JSON value for FourDigitDate: %w", err)
+	}
+
+	d.Time = time.Date(0, v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// DateTime captures date time indication in YYMMDDhhmm+hhmm format (with UTC offset).
+type DateTime struct {
+	time.Time
+}
+
+const dateTimeLayout = "0601021504-0700"
+
+func (d *DateTime) Capture(values []string) error {
+	if len(values) != 1 {
+		return fmt.Errorf("bad capture length for DateTime: %v", values)
+	}
+	v, err := time.Parse(dateTimeLayout, values[0])
+	if err != nil {
+		return err
+	}
+
+	d.Time = v
+	return nil
+}
+
+// MTString formats date time in YYMMDDhhmm+hhmm format.
+func (d DateTime) MTString() string {
+	return d.Format(dateTimeLayout)
+}
+
+// MarshalJSON serializes date time as RFC 3339 timestamp with the original UTC offset.
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(JSONDateLayout))
+}
+
+// JSONSchema describes JSON representation of the date time.
+func (DateTime) JSONSchema() map[string]any {
+	return map[string]any{"type": "string", "format": "date-time"}
+}
+
+// UnmarshalJSON accepts RFC 3339 timestamp or YYMMDDhhmm+hhmm date time.
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for DateTime: %w", err)
+	}
+	if s == "null" {
+		return nil
+	}
+	v, err := parseJSONDate(s, dateTimeLayout)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for DateTime: %w", err)
+	}
+
+	d.Time = v
+	return nil
+}
+
+// Count captures number of entries, digits are kept as captured, so leading zeros are serialized back.
+type Count struct {
+	Value  int
+	digits string
+}
+
+// NewCount creates count serialized without leading zeros.
+func NewCount(v int) Count {
+	return Count{Value: v}
+}
+
+func (c *Count) Capture(values []string) error {
+	if len(values) != 1 {
+		return fmt.Errorf("bad capture length for Count: %v", values)
+	}
+	v, err := strconv.Atoi(values[0])
+	if err != nil {
+		return err
+	}
+
+	c.Value = v
+	c.digits = values[0]
+	return nil
+}
+
+// MTString formats count with digits as captured, if they still represent the value.
+func (c Count) MTString() string {
+	if v, err := strconv.Atoi(c.digits); err == nil && v == c.Value {
+		return c.digits
+	}
+	return strconv.Itoa(c.Value)
+}
+
+// MarshalJSON serializes count as a JSON number.
+func (c Count) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Value)
+}
+
+// JSONSchema describes JSON representation of the count.
+func (Count) JSONSchema() map[string]any {
+	return map[string]any{"type": "integer", "minimum": 0}
+}
+
+// UnmarshalJSON accepts JSON number or string, digits of the string are kept as captured ones.
+func (c *Count) UnmarshalJSON(data []byte) error {
+	s, err := unquoteJSON(data)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for Count: %w", err)
+	}
+	if s == "null" {
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("bad JSON value for Count: %w", err)
+	}
+
+	c.Value = v
+	c.digits = ""
+	if len(data) > 0 && data[0] == '"' {
+		c.digits = s
+	}
+	return nil
+}
+
+// PartyOption captures option letter from the party field tag, e.g. A for :52A:.
+type PartyOption string
+
+func (o *PartyOption) Capture(values []string) error {
+	if len(values) != 1 {
+		return fmt.Errorf("bad capture length for PartyOption: %v", values)
+	}
+
+	tag := strings.Trim(values[0], ":")
+	if tag == "" {
+		return fmt.Errorf("bad party field tag: %s", values[0])
+	}
+
+	*o = PartyOption(tag[len(tag)-1:])
+	return nil
+}
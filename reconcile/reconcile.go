@@ -0,0 +1,226 @@
+// Package reconcile matches statement transactions to expected open items, e.g. invoices.
+package reconcile
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oswida/mt9x/grammar"
+	"github.com/shopspring/decimal"
+)
+
+// Matching methods.
+const (
+	// Item reference is found in the transaction references or remittance information.
+	ReferenceMethod = "reference"
+	// Amount is the same (within tolerance) and value date is close to the item date.
+	AmountDateMethod = "amount_date"
+	// Remittance information contains words of the item reference and counterparty.
+	FuzzyMethod = "fuzzy"
+)
+
+// Item is an expected payment.
+type Item struct {
+	ID        string `json:"id"`
+	Reference string `json:"reference,omitempty"`
+	// Expected amount with the sign of the balance change: positive for incoming, negative for outgoing payments.
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency"`
+	Counterparty string          `json:"counterparty,omitempty"`
+	// Expected payment date, used by amount and date matching.
+	Date *time.Time `json:"date,omitempty"`
+}
+
+// Options of the matching.
+type Options struct {
+	// Maximal difference of amounts treated as the same amount.
+	AmountTolerance decimal.Decimal
+	// Maximal number of days between the item date and the transaction value date.
+	DateTolerance int
+	// Minimal ratio of item reference and counterparty words found in the transaction for fuzzy matches,
+	// 0.6 is used when not set.
+	MinWordRatio float64
+}
+
+// Match is a transaction matched to an item.
+type Match struct {
+	Item        Item                `json:"item"`
+	Transaction grammar.Transaction `json:"transaction"`
+	Method      string              `json:"method"`
+	// Confidence of the match, from 0 to 1.
+	Score float64 `json:"score"`
+	// Item amount minus transaction signed amount.
+	Difference decimal.Decimal `json:"difference"`
+}
+
+// Result contains matches and items and transactions which were not matched.
+type Result struct {
+	// Matches with the same amounts (within tolerance).
+	Matched []Match `json:"matched"`
+	// Matches of partial payments, with transaction amount smaller than the item amount.
+	Partial               []Match               `json:"partial"`
+	UnmatchedItems        []Item                `json:"unmatched_items"`
+	UnmatchedTransactions []grammar.Transaction `json:"unmatched_transactions"`
+}
+
+// Reconcile matches transactions of the messages to the items. Every item and transaction is matched at most once,
+// reference matches are found first, then amount and date matches and finally fuzzy matches.
+func Reconcile(items []Item, messages []grammar.MT940Message, options Options) Result {
+	if options.MinWordRatio == 0 {
+		options.MinWordRatio = 0.6
+	}
+	transactions := grammar.Transactions(messages...)
+	m := matcher{
+		options:      options,
+		items:        items,
+		transactions: transactions,
+		usedItems:    make([]bool, len(items)),
+		usedTrx:      make([]bool, len(transactions)),
+	}
+	m.pass(ReferenceMethod, m.referenceScore)
+	m.pass(AmountDateMethod, m.amountDateScore)
+	m.pass(FuzzyMethod, m.fuzzyScore)
+
+	result := Result{Matched: []Match{}, Partial: []Match{}, UnmatchedItems: []Item{}, UnmatchedTransactions: []grammar.Transaction{}}
+	for _, match := range m.matches {
+		if m.sameAmount(match.Difference) {
+			result.Matched = append(result.Matched, match)
+		} else {
+			result.Partial = append(result.Partial, match)
+		}
+	}
+	for i, item := range items {
+		if !m.usedItems[i] {
+			result.UnmatchedItems = append(result.UnmatchedItems, item)
+		}
+	}
+	for i, t := range transactions {
+		if !m.usedTrx[i] {
+			result.UnmatchedTransactions = append(result.UnmatchedTransactions, t)
+		}
+	}
+	return result
+}
+
+type matcher struct {
+	options      Options
+	items        []Item
+	transactions []grammar.Transaction
+	usedItems    []bool
+	usedTrx      []bool
+	matches      []Match
+}
+
+type candidate struct {
+	item, trx int
+	score     float64
+}
+
+// pass matches not used items and transactions with the best scores first, zero score means no match.
+func (m *matcher) pass(method string, score func(Item, grammar.Transaction) float64) {
+	candidates := []candidate{}
+	for i, item := range m.items {
+		if m.usedItems[i] {
+			continue
+		}
+		for j, t := range m.transactions {
+			if m.usedTrx[j] || t.Currency != item.Currency {
+				continue
+			}
+			if s := score(item, t); s > 0 {
+				candidates = append(candidates, candidate{i, j, s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].score > candidates[b].score })
+	for _, c := range candidates {
+		if m.usedItems[c.item] || m.usedTrx[c.trx] {
+			continue
+		}
+		m.usedItems[c.item], m.usedTrx[c.trx] = true, true
+		item, t := m.items[c.item], m.transactions[c.trx]
+		m.matches = append(m.matches, Match{Item: item, Transaction: t, Method: method, Score: c.score,
+			Difference: item.Amount.Sub(t.SignedAmount)})
+	}
+}
+
+func (m *matcher) sameAmount(difference decimal.Decimal) bool {
+	return difference.Abs().LessThanOrEqual(m.options.AmountTolerance)
+}
+
+// partialAmount checks if the transaction is a partial payment of the item: its amount has the same sign
+// and is smaller than the item amount.
+func partialAmount(item Item, t grammar.Transaction) bool {
+	return item.Amount.Sign()*t.SignedAmount.Sign() > 0 && t.SignedAmount.Abs().LessThan(item.Amount.Abs())
+}
+
+func (m *matcher) referenceScore(item Item, t grammar.Transaction) float64 {
+	ref := normalize(item.Reference)
+	if ref == "" {
+		return 0
+	}
+	same := m.sameAmount(item.Amount.Sub(t.SignedAmount))
+	if !same && !partialAmount(item, t) {
+		return 0
+	}
+	for _, text := range []string{t.Reference, t.InstitutionReference, t.OwnerInfo.EndToEndReference, t.OwnerInfo.Purpose, t.OwnerInfo.Text} {
+		if strings.Contains(" "+normalize(text)+" ", " "+ref+" ") {
+			if same {
+				return 1
+			}
+			return 0.9
+		}
+	}
+	return 0
+}
+
+func (m *matcher) amountDateScore(item Item, t grammar.Transaction) float64 {
+	if !m.sameAmount(item.Amount.Sub(t.SignedAmount)) {
+		return 0
+	}
+	if item.Date == nil {
+		return 0.7
+	}
+	days := int(t.ValueDate.Sub(*item.Date).Abs().Hours() / 24)
+	if days > m.options.DateTolerance {
+		return 0
+	}
+	// Closer dates give better score.
+	return 0.8 - 0.1*float64(days)/float64(m.options.DateTolerance+1)
+}
+
+func (m *matcher) fuzzyScore(item Item, t grammar.Transaction) float64 {
+	words := strings.Fields(normalize(item.Reference + " " + item.Counterparty))
+	if len(words) == 0 {
+		return 0
+	}
+	same := m.sameAmount(item.Amount.Sub(t.SignedAmount))
+	if !same && !partialAmount(item, t) {
+		return 0
+	}
+	text := " " + normalize(strings.Join([]string{t.Reference, t.OwnerInfo.Text, t.OwnerInfo.CounterpartyName}, " ")) + " "
+	found := 0
+	for _, w := range words {
+		if strings.Contains(text, " "+w+" ") {
+			found++
+		}
+	}
+	ratio := float64(found) / float64(len(words))
+	if ratio < m.options.MinWordRatio {
+		return 0
+	}
+	score := 0.6 * ratio
+	if same {
+		score += 0.2
+	}
+	return score
+}
+
+var separators = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// normalize returns upper case words of the text separated by single spaces.
+func normalize(text string) string {
+	return strings.TrimSpace(separators.ReplaceAllString(strings.ToUpper(text), " "))
+}
@@ -0,0 +1,65 @@
+package reconcile_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/oswida/mt9x/grammar"
+	"github.com/oswida/mt9x/parser"
+	"github.com/oswida/mt9x/reconcile"
+	"github.com/shopspring/decimal"
+)
+
+func TestReconcile(t *testing.T) {
+	fp := parser.NewFileParser[grammar.MT940Message]()
+	m, err := fp.Parse(filepath.Join("..", "parser", "testdata", "mt940", "input", "io-payments.sta"), false, nil)
+	assert.NoError(t, err)
+	date := time.Date(2015, 4, 22, 0, 0, 0, 0, time.UTC)
+	items := []reconcile.Item{
+		{ID: "inv-1", Reference: "1177000222", Amount: decimal.RequireFromString("3186.65"), Currency: "GBP"},
+		{ID: "inv-2", Reference: "0133710", Amount: decimal.RequireFromString("3000"), Currency: "GBP"},
+		{ID: "pay-3", Amount: decimal.RequireFromString("-434.40"), Currency: "GBP", Date: &date},
+		{ID: "inv-4", Counterparty: "Sender of payment", Amount: decimal.RequireFromString("2500"), Currency: "GBP"},
+		{ID: "missing", Reference: "NOPE", Amount: decimal.RequireFromString("1"), Currency: "GBP"},
+		{ID: "usd", Reference: "123456", Amount: decimal.RequireFromString("2510.83"), Currency: "USD"},
+	}
+	result := reconcile.Reconcile(items, []grammar.MT940Message{*m}, reconcile.Options{
+		AmountTolerance: decimal.RequireFromString("0.01"),
+		DateTolerance:   2,
+	})
+
+	type summary struct {
+		ID, Method, Difference string
+		Score                  float64
+	}
+	summarize := func(matches []reconcile.Match) []summary {
+		result := []summary{}
+		for _, m := range matches {
+			result = append(result, summary{m.Item.ID, m.Method, m.Difference.String(), m.Score})
+		}
+		return result
+	}
+	assert.Equal(t, []summary{
+		{"inv-1", reconcile.ReferenceMethod, "0", 1},
+		{"pay-3", reconcile.AmountDateMethod, "-0.01", 0.8 - 0.1/3},
+	}, summarize(result.Matched))
+	assert.Equal(t, []summary{
+		{"inv-2", reconcile.ReferenceMethod, "565.6", 0.9},
+	}, summarize(result.Partial))
+	// Transaction amount greater than the item amount is not a partial payment.
+	assert.Equal(t, []reconcile.Item{items[3], items[4], items[5]}, result.UnmatchedItems)
+	unmatched := []string{}
+	for _, t := range result.UnmatchedTransactions {
+		unmatched = append(unmatched, t.SignedAmount.String())
+	}
+	assert.Equal(t, []string{"39351.8", "2510.83", "-1172.28"}, unmatched)
+
+	// Transactions with the opposite sign are not matched.
+	refund := reconcile.Item{ID: "refund", Reference: "0133710", Amount: decimal.RequireFromString("-3000"), Currency: "GBP"}
+	result = reconcile.Reconcile([]reconcile.Item{refund}, []grammar.MT940Message{*m}, reconcile.Options{})
+	assert.Zero(t, result.Matched)
+	assert.Zero(t, result.Partial)
+	assert.Equal(t, []reconcile.Item{refund}, result.UnmatchedItems)
+}